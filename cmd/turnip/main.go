@@ -8,21 +8,38 @@ import (
 	"github.com/charmbracelet/log"
 
 	"github.com/ivanvc/turnip/internal/adapters/github"
+	"github.com/ivanvc/turnip/internal/adapters/gitlab"
+	"github.com/ivanvc/turnip/internal/cli"
 	"github.com/ivanvc/turnip/internal/common"
 	"github.com/ivanvc/turnip/internal/config"
 	"github.com/ivanvc/turnip/internal/http"
 	"github.com/ivanvc/turnip/internal/rpc"
+	"github.com/ivanvc/turnip/internal/scheduler"
+	"github.com/ivanvc/turnip/internal/services/docker"
+	"github.com/ivanvc/turnip/internal/services/executor"
 	"github.com/ivanvc/turnip/internal/services/kubernetes"
+	"github.com/ivanvc/turnip/internal/services/local"
 )
 
 func main() {
+	if cli.IsCommand(os.Args[1:]) {
+		if err := cli.Execute(); err != nil {
+			os.Exit(1)
+		}
+		return
+	}
+
 	cfg := config.Load()
 	log.Default().SetReportCaller(true)
 	log.Default().SetLevel(log.ParseLevel(cfg.LogLevel))
 	common := &common.Common{
-		Config:           cfg,
-		KubernetesClient: kubernetes.LoadClient(cfg),
-		GitHubClient:     github.NewClient(cfg),
+		Config:       cfg,
+		Executor:     loadExecutor(cfg),
+		GitHubClient: github.NewClient(cfg),
+		Scheduler:    scheduler.New(cfg.DependencyTimeout),
+	}
+	if cfg.GitLabURL != "" {
+		common.GitLabClient = gitlab.NewClient(cfg)
 	}
 
 	s := http.NewServer(common)
@@ -36,3 +53,20 @@ func main() {
 	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 	<-done
 }
+
+// loadExecutor returns the executor the server is configured to run the jobs
+// with.
+func loadExecutor(cfg *config.Config) executor.Executor {
+	switch cfg.Executor {
+	case "local":
+		return local.NewExecutor(cfg)
+	case "docker":
+		return docker.NewExecutor(cfg)
+	default:
+		c, err := kubernetes.LoadClient(cfg)
+		if err != nil {
+			log.Fatal("error loading Kubernetes client, set the executor to local or docker to run outside a cluster", "error", err)
+		}
+		return c
+	}
+}
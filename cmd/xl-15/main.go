@@ -2,9 +2,11 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/log"
@@ -13,14 +15,27 @@ import (
 
 	"github.com/ivanvc/turnip/internal/job/commands"
 	intgit "github.com/ivanvc/turnip/internal/job/git"
+	"github.com/ivanvc/turnip/internal/job/plugin"
+	"github.com/ivanvc/turnip/internal/job/redact"
 	"github.com/ivanvc/turnip/internal/yaml"
 	pb "github.com/ivanvc/turnip/pkg/turnip"
 )
 
 const connRetries = 5
 
+// maxOutputSize is the size after which the output is truncated, leaving room
+// in the message for the rest of the request.
+const maxOutputSize = pb.MaxMessageSize - 64<<10
+
 func main() {
-	conn, err := grpc.Dial(fmt.Sprintf("%s:50001", os.Getenv("TURNIP_SERVER_NAME")), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := grpc.Dial(
+		fmt.Sprintf("%s:50001", os.Getenv("TURNIP_SERVER_NAME")),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(
+			grpc.MaxCallSendMsgSize(pb.MaxMessageSize),
+			grpc.MaxCallRecvMsgSize(pb.MaxMessageSize),
+		),
+	)
 	if err != nil {
 		log.Fatalf("could not connect to RPC: %v", err)
 	}
@@ -59,6 +74,9 @@ func main() {
 		CheckName:        os.Getenv("TURNIP_CHECK_NAME"),
 		CommentsUrl:      os.Getenv("TURNIP_COMMENTS_URL"),
 		Command:          os.Getenv("TURNIP_COMMAND"),
+		HeadSha:          os.Getenv("TURNIP_HEAD_SHA"),
+		Adapter:          project.GetAdapterName(),
+		ProjectName:      project.Name,
 		ProjectDir:       project.Dir,
 		ProjectWorkspace: project.GetWorkspace(),
 	}
@@ -70,11 +88,17 @@ func main() {
 	} else {
 		req.Status = pb.JobStatus_SUCCEEDED
 	}
-	req.Output = output
-	if err != nil {
-		req.Error = err.Error()
+	if redactor, rerr := newRedactor(project); rerr != nil {
+		log.Error("error loading redactor, omitting output", "error", rerr)
+		req.Status = pb.JobStatus_FAILED
+		req.Error = fmt.Sprintf("error loading redact patterns, output omitted: %v", rerr)
+	} else {
+		req.Output = truncateOutput(redactor.Redact(output))
+		if err != nil {
+			req.Error = redactor.RedactString(err.Error())
+		}
 	}
-	log.Info("Job Finished request", "req", req)
+	log.Info("Job Finished request", "checkName", req.CheckName, "status", req.Status, "outputSize", len(req.Output), "error", req.Error)
 
 	for i := 0; i < connRetries; i++ {
 		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
@@ -87,6 +111,38 @@ func main() {
 	}
 }
 
+// truncateOutput returns the output truncated to fit the report, so it's never
+// rejected by the server.
+func truncateOutput(output []byte) []byte {
+	if len(output) <= maxOutputSize {
+		return output
+	}
+	log.Warn("output is too large, truncating it", "size", len(output))
+	note := []byte("\n... output truncated, it exceeds the maximum report size.")
+	return append(output[:maxOutputSize-len(note):maxOutputSize-len(note)], note...)
+}
+
+// newRedactor returns a redactor that masks the values of the job secrets,
+// and the matches of the server and workflow redact patterns.
+func newRedactor(project yaml.Project) (*redact.Redactor, error) {
+	values := []string{os.Getenv("TURNIP_GITHUB_TOKEN"), os.Getenv("TURNIP_GITLAB_TOKEN")}
+	for _, name := range strings.Split(os.Getenv("TURNIP_SECRET_ENV_NAMES"), ",") {
+		if name != "" {
+			values = append(values, os.Getenv(name))
+		}
+	}
+
+	var patterns []string
+	if p := os.Getenv("TURNIP_REDACT_PATTERNS"); p != "" {
+		if err := json.Unmarshal([]byte(p), &patterns); err != nil {
+			return nil, err
+		}
+	}
+	patterns = append(patterns, project.LoadedWorkflow.RedactPatterns...)
+
+	return redact.New(values, patterns)
+}
+
 func run(project yaml.Project, extraArgs string) (bool, []byte, error) {
 	tmpDir, err := os.MkdirTemp("", "turnip-repo-*")
 	if err != nil {
@@ -101,46 +157,37 @@ func run(project yaml.Project, extraArgs string) (bool, []byte, error) {
 		return false, []byte{}, err
 	}
 
-	if err := os.MkdirAll("/opt/turnip/bin", 0750); err != nil && !os.IsExist(err) {
+	if dir := os.Getenv("TURNIP_BIN_DIR"); dir != "" {
+		plugin.BinDir = dir
+	}
+	if err := os.MkdirAll(plugin.BinDir, 0750); err != nil && !os.IsExist(err) {
 		log.Error("Error creating bin dir", "error", err)
 		return false, []byte{}, err
 	}
 
+	cloneURL := os.Getenv("TURNIP_CLONE_URL")
+	username, token := cloneCredentials(cloneURL)
 	if err := intgit.Clone(
 		repoDir,
-		os.Getenv("TURNIP_CLONE_URL"),
+		cloneURL,
 		os.Getenv("TURNIP_HEAD_REF"),
-		os.Getenv("TURNIP_GITHUB_TOKEN"),
+		username,
+		token,
 	); err != nil {
 		log.Error("error cloning", "error", err)
 		return false, []byte{}, err
 	}
 
-	if output, err := commands.Install(tmpDir, repoDir, project); err != nil {
-		log.Error("error installing dependencies", "error", err)
-		return false, output, err
-	}
-
-	output, err := commands.RunInitCommands(repoDir, project)
-	if err != nil {
-		log.Error("error running pre commands", "error", err, "output", string(output))
-		return false, output, err
-	}
+	return commands.RunJob(tmpDir, repoDir, os.Getenv("TURNIP_COMMAND"), project, extraArgs)
+}
 
-	var returnCode bool
-	switch os.Getenv("TURNIP_COMMAND") {
-	case "plot":
-		returnCode, output, err = commands.Plot(repoDir, project, extraArgs)
-		if err != nil {
-			log.Error("error running plot", "error", err)
-		}
-	case "lift":
-		returnCode, output, err = commands.Lift(repoDir, project, extraArgs)
-		if err != nil {
-			log.Error("error running lift", "error", err)
-		}
+// cloneCredentials returns the credentials to clone the repository, the
+// GitLab token if it's hosted in the server's GitLab, or the GitHub token.
+func cloneCredentials(cloneURL string) (string, string) {
+	if u := os.Getenv("TURNIP_GITLAB_URL"); u != "" && strings.HasPrefix(cloneURL, strings.TrimSuffix(u, "/")+"/") {
+		return "oauth2", os.Getenv("TURNIP_GITLAB_TOKEN")
 	}
-	return returnCode, output, err
+	return "token", os.Getenv("TURNIP_GITHUB_TOKEN")
 }
 
 /*
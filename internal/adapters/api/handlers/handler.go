@@ -5,12 +5,19 @@ import (
 
 	"github.com/charmbracelet/log"
 	"github.com/ivanvc/turnip/internal/adapters/api/objects"
-	githubobjects "github.com/ivanvc/turnip/internal/adapters/github/objects"
 	"github.com/ivanvc/turnip/internal/common"
+	"github.com/ivanvc/turnip/internal/discovery"
+	"github.com/ivanvc/turnip/internal/plugin"
+	"github.com/ivanvc/turnip/internal/template"
+	"github.com/ivanvc/turnip/internal/vcs"
 	"github.com/ivanvc/turnip/internal/yaml"
 )
 
 func Handle(common *common.Common, verb string, payload *objects.APIRequest) (*objects.APIResponse, error) {
+	if _, ok := common.RepoConfig.Find(payload.Repo); !ok {
+		return nil, fmt.Errorf("repository %s is not allowed by the server", payload.Repo)
+	}
+
 	prj, err := getProject(common, payload)
 	if err != nil {
 		return nil, err
@@ -20,28 +27,31 @@ func Handle(common *common.Common, verb string, payload *objects.APIRequest) (*o
 }
 
 func getProject(common *common.Common, payload *objects.APIRequest) (*yaml.Project, error) {
-	repo := githubobjects.Repository{
-		ContentsURL: fmt.Sprintf("https://api.github.com/repos/%s/contents/{+path}", payload.Repo),
-	}
-	ref := githubobjects.BranchRef{
-		Ref: payload.Ref,
+	ref := vcs.Ref{
+		Ref:     payload.Ref,
+		Repo:    payload.Repo,
+		RepoURL: "https://api.github.com/repos/" + payload.Repo,
 	}
 
-	yml, err := common.GitHubClient.FetchFile("turnip.yaml", repo, ref)
+	// The API is authenticated, so the configuration is always loaded from the
+	// requested ref.
+	settings, _ := common.RepoConfig.Find(payload.Repo)
+	cfg, err := discovery.LoadConfig(common.GitHubClient.NewSource(ref), common.RepoConfig.Policy(settings))
 	if err != nil {
-		log.Error("error fetching turnip.yaml", "error", err)
 		return nil, err
 	}
-	log.Debug("fetched turnip.yaml", "content", string(yml))
-
-	cfg, err := yaml.Load(yml)
-	if err != nil {
-		log.Error("error parsing configuration", "error", err)
+	if err := plugin.Validate(cfg); err != nil {
+		log.Error("error validating configuration", "error", err)
 		return nil, err
 	}
-	log.Debug("yaml configuration", "cfg", cfg)
 
 	for _, prj := range cfg.Projects {
+		if payload.Project != "" {
+			if prj.Name == payload.Project {
+				return &prj, nil
+			}
+			continue
+		}
 		if prj.Dir == payload.Dir {
 			switch prj.GetWorkspace() {
 			case payload.Workspace, payload.Environment, payload.Stack:
@@ -54,12 +64,10 @@ func getProject(common *common.Common, payload *objects.APIRequest) (*yaml.Proje
 }
 
 func triggerProject(common *common.Common, cmdName string, project *yaml.Project, payload *objects.APIRequest) (*objects.APIResponse, error) {
-	var cmd string
-	switch cmdName {
-	case "plot":
-		cmd = project.GetPlotName()
-	case "lift":
-		cmd = project.GetLiftName()
+	name, err := plugin.CheckName(project, cmdName)
+	if err != nil {
+		log.Error("error getting check name", "error", err)
+		return nil, err
 	}
 
 	commit, err := common.GitHubClient.GetCommitFromRef(payload.Repo, payload.Ref)
@@ -68,11 +76,11 @@ func triggerProject(common *common.Common, cmdName string, project *yaml.Project
 		return nil, err
 	}
 
-	name := fmt.Sprintf("turnip/%s/%s/%s/%s", project.GetAdapterName(), cmd, project.Dir, project.GetWorkspace())
 	checkURL, err := common.GitHubClient.CreateCheckRun(
 		fmt.Sprintf("https://api.github.com/repos/%s/statuses/{sha}", payload.Repo),
 		commit.SHA,
 		name,
+		"Queued",
 	)
 	if err != nil {
 		log.Error("error creating check run", "error", err)
@@ -82,7 +90,23 @@ func triggerProject(common *common.Common, cmdName string, project *yaml.Project
 	log.Debug("creating job", "checkURL", checkURL)
 	cloneURL := fmt.Sprintf("https://github.com/%s.git", payload.Repo)
 
-	if err := common.KubernetesClient.CreateJob(cmdName, cloneURL, payload.Ref, payload.Repo, checkURL, name, commit.CommentsURL, payload.ExtraArgs, project); err != nil {
+	tpl := template.New(*project, template.Environment{
+		Repo:    payload.Repo,
+		HeadSHA: commit.SHA,
+		Command: cmdName,
+	})
+	rendered, err := tpl.Render(*project)
+	if err != nil {
+		log.Error("error rendering project", "error", err)
+		return nil, err
+	}
+	extraArgs, err := tpl.Execute(payload.ExtraArgs)
+	if err != nil {
+		log.Error("error rendering extra args", "error", err)
+		return nil, err
+	}
+
+	if err := common.Executor.CreateJob(cmdName, cloneURL, payload.Ref, commit.SHA, payload.Repo, checkURL, name, commit.CommentsURL, extraArgs, &rendered); err != nil {
 		log.Error("error creating job", "error", err)
 		return nil, err
 	}
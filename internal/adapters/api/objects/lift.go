@@ -2,8 +2,11 @@ package objects
 
 // APIRequest is the payload for an API call.
 type APIRequest struct {
-	Repo        string `json:"repo"`
-	Ref         string `json:"ref"`
+	Repo string `json:"repo"`
+	Ref  string `json:"ref"`
+	// Project is the name of the project. If it's not set, the project is
+	// looked up by its dir and workspace, environment or stack.
+	Project     string `json:"project"`
 	Dir         string `json:"dir"`
 	Workspace   string `json:"workspace"`
 	Environment string `json:"environment"`
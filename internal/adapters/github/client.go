@@ -4,15 +4,19 @@ import (
 	"bytes"
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strings"
 
 	"github.com/charmbracelet/log"
 
 	"github.com/ivanvc/turnip/internal/adapters/github/objects"
 	"github.com/ivanvc/turnip/internal/config"
+	"github.com/ivanvc/turnip/internal/discovery"
+	"github.com/ivanvc/turnip/internal/vcs"
 )
 
 type statusRequest struct {
@@ -30,12 +34,19 @@ type checkRunRequest struct {
 	Conclusion  string `json:"conclusion,omitempty"`
 }
 
+const graphQLURL = "https://api.github.com/graphql"
+
+var nextPageRegexp = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+var issueCommentsRegexp = regexp.MustCompile(`^(.+)/issues/(\d+)/comments$`)
+
 type Client struct {
 	token string
+	cache *discovery.Cache
 }
 
 func NewClient(cfg *config.Config) *Client {
-	return &Client{cfg.GitHubToken}
+	return &Client{cfg.GitHubToken, discovery.NewCache(sourceCacheSize)}
 }
 
 func (c *Client) GetPullRequestFromIssueComment(ic *objects.IssueComment) (*objects.PullRequest, error) {
@@ -62,6 +73,36 @@ func (c *Client) GetPullRequestFromIssueComment(ic *objects.IssueComment) (*obje
 	return &pr, nil
 }
 
+// HeadSHA returns the head commit of the pull request of the comments URL, or
+// an empty string if they are a commit's comments.
+func (c *Client) HeadSHA(commentsURL string) (string, error) {
+	m := issueCommentsRegexp.FindStringSubmatch(commentsURL)
+	if m == nil {
+		return "", nil
+	}
+	u, err := c.parseURL(m[1] + "/pulls/" + m[2])
+	if err != nil {
+		log.Error("Error parsing URL", "error", err)
+		return "", err
+	}
+
+	resp, err := http.Get(u.String())
+	if err != nil {
+		log.Error("Error fetching Pull Request", "error", err)
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("error fetching pull request: %s", resp.Status)
+	}
+	var pr objects.PullRequest
+	if err := json.NewDecoder(resp.Body).Decode(&pr); err != nil {
+		log.Error("Error unmarshalling", "error", err)
+		return "", err
+	}
+	return pr.Head.SHA, nil
+}
+
 func (c *Client) GetCommitFromRef(repo, ref string) (*objects.Commit, error) {
 	u, err := c.parseURL("https://api.github.com/repos/" + repo + "/commits/" + ref)
 	if err != nil {
@@ -86,7 +127,7 @@ func (c *Client) GetCommitFromRef(repo, ref string) (*objects.Commit, error) {
 	return &commit, nil
 }
 
-func (c *Client) CreateCheckRun(statusesURL, sha, name string) (string, error) {
+func (c *Client) CreateCheckRun(statusesURL, sha, name, description string) (string, error) {
 	u, err := c.parseURL(strings.Replace(statusesURL, "{sha}", sha, 1))
 	if err != nil {
 		log.Error("Error parsing URL", "error", err)
@@ -95,7 +136,7 @@ func (c *Client) CreateCheckRun(statusesURL, sha, name string) (string, error) {
 
 	req := statusRequest{
 		State:       "pending",
-		Description: "Queued",
+		Description: description,
 		Context:     name,
 	}
 
@@ -165,6 +206,32 @@ func (c *Client) FinishCheckRun(checkURL, checkName, conclusion string) error {
 	return err
 }
 
+// UpdateCheckRun sets the state of the check run with a description, which
+// GitHub limits to 140 characters.
+func (c *Client) UpdateCheckRun(checkURL, checkName, state, description string) error {
+	u, err := c.parseURL(checkURL)
+	if err != nil {
+		log.Error("Error parsing URL", "error", err)
+		return err
+	}
+
+	if len(description) > 140 {
+		description = description[:137] + "..."
+	}
+	req := statusRequest{
+		State:       state,
+		Context:     checkName,
+		Description: description,
+	}
+
+	jsonValue, _ := json.Marshal(req)
+	_, err = http.Post(u.String(), "application/json", bytes.NewBuffer(jsonValue))
+	if err != nil {
+		log.Error("Error updating check", "error", err)
+	}
+	return err
+}
+
 func (c *Client) ReactToComment(reactionsURL, reaction string) error {
 	u, err := c.parseURL(reactionsURL)
 	if err != nil {
@@ -207,6 +274,183 @@ func (c *Client) CreateComment(commentsURL, body string) error {
 	return err
 }
 
+// ListComments returns all the comments from the given comments URL.
+func (c *Client) ListComments(commentsURL string) ([]vcs.Comment, error) {
+	u, err := c.parseURL(commentsURL)
+	if err != nil {
+		log.Error("Error parsing URL", "error", err)
+		return nil, err
+	}
+	q := u.Query()
+	q.Set("per_page", "100")
+	u.RawQuery = q.Encode()
+
+	comments := make([]vcs.Comment, 0)
+	next := u.String()
+	for next != "" {
+		resp, err := http.Get(next)
+		if err != nil {
+			log.Error("Error listing comments", "error", err)
+			return nil, err
+		}
+
+		var page []objects.Comment
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			log.Error("Error unmarshalling", "error", err)
+			return nil, err
+		}
+		for _, c := range page {
+			comments = append(comments, vcs.Comment{URL: c.URL, Body: c.Body, NodeID: c.NodeID})
+		}
+
+		next = ""
+		if m := nextPageRegexp.FindStringSubmatch(resp.Header.Get("Link")); m != nil {
+			nu, err := c.parseURL(m[1])
+			if err != nil {
+				log.Error("Error parsing URL", "error", err)
+				return nil, err
+			}
+			next = nu.String()
+		}
+	}
+
+	return comments, nil
+}
+
+// IsApproved returns whether the reviews of the pull request approve its head
+// commit.
+func (c *Client) IsApproved(pr *vcs.PullRequest) (bool, error) {
+	reviews, err := c.ListReviews(pr)
+	if err != nil {
+		return false, err
+	}
+	return objects.IsApproved(reviews, pr.Head.SHA), nil
+}
+
+// ListReviews returns all the reviews of the pull request.
+func (c *Client) ListReviews(pr *vcs.PullRequest) ([]objects.Review, error) {
+	u, err := c.parseURL(pr.URL + "/reviews")
+	if err != nil {
+		log.Error("Error parsing URL", "error", err)
+		return nil, err
+	}
+	q := u.Query()
+	q.Set("per_page", "100")
+	u.RawQuery = q.Encode()
+
+	reviews := make([]objects.Review, 0)
+	next := u.String()
+	for next != "" {
+		resp, err := http.Get(next)
+		if err != nil {
+			log.Error("Error listing reviews", "error", err)
+			return nil, err
+		}
+
+		var page []objects.Review
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			log.Error("Error unmarshalling", "error", err)
+			return nil, err
+		}
+		reviews = append(reviews, page...)
+
+		next = ""
+		if m := nextPageRegexp.FindStringSubmatch(resp.Header.Get("Link")); m != nil {
+			nu, err := c.parseURL(m[1])
+			if err != nil {
+				log.Error("Error parsing URL", "error", err)
+				return nil, err
+			}
+			next = nu.String()
+		}
+	}
+
+	return reviews, nil
+}
+
+// UpdateComment replaces the body of the comment with the given URL.
+func (c *Client) UpdateComment(commentURL, body string) error {
+	u, err := c.parseURL(commentURL)
+	if err != nil {
+		log.Error("Error parsing URL", "error", err)
+		return err
+	}
+
+	payload := struct {
+		Body string `json:"body"`
+	}{body}
+
+	jsonValue, _ := json.Marshal(payload)
+	req, err := http.NewRequest(http.MethodPatch, u.String(), bytes.NewBuffer(jsonValue))
+	if err != nil {
+		log.Error("Error creating request", "error", err)
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Error("Error updating comment", "error", err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("error updating comment: %s", resp.Status)
+	}
+	return nil
+}
+
+// MinimizeComment hides the comment, using the classifier as the reason (i.e.
+// OUTDATED).
+func (c *Client) MinimizeComment(comment vcs.Comment, classifier string) error {
+	payload := struct {
+		Query     string         `json:"query"`
+		Variables map[string]any `json:"variables"`
+	}{
+		Query: `mutation($id: ID!, $classifier: ReportedContentClassifiers!) {
+  minimizeComment(input: {subjectId: $id, classifier: $classifier}) {
+    minimizedComment { isMinimized }
+  }
+}`,
+		Variables: map[string]any{"id": comment.NodeID, "classifier": classifier},
+	}
+
+	jsonValue, _ := json.Marshal(payload)
+	req, err := http.NewRequest(http.MethodPost, graphQLURL, bytes.NewBuffer(jsonValue))
+	if err != nil {
+		log.Error("Error creating request", "error", err)
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "bearer "+c.token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Error("Error minimizing comment", "error", err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		log.Error("Error unmarshalling", "error", err)
+		return err
+	}
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("error minimizing comment: %s", result.Errors[0].Message)
+	}
+	return nil
+}
+
 func (c *Client) FetchFile(path string, repo objects.Repository, ref objects.BranchRef) ([]byte, error) {
 	u, err := c.parseURL(strings.Replace(repo.ContentsURL, "{+path}", path, 1))
 	if err != nil {
@@ -244,7 +488,60 @@ func (c *Client) FetchFile(path string, repo objects.Repository, ref objects.Bra
 	return data, nil
 }
 
-func (c *Client) GetPullRequestDiff(pr *objects.PullRequest) ([]byte, error) {
+// ListTree returns the paths of the files in the repository at the given
+// revision, using the Git trees API.
+func (c *Client) ListTree(repo objects.Repository, ref objects.BranchRef) ([]string, error) {
+	rev := ref.SHA
+	if rev == "" {
+		rev = ref.Ref
+	}
+	u, err := c.parseURL(strings.Replace(repo.TreesURL, "{/sha}", "/"+url.PathEscape(rev), 1))
+	if err != nil {
+		log.Error("error parsing URL", "error", err)
+		return nil, err
+	}
+
+	q := u.Query()
+	q.Set("recursive", "1")
+	u.RawQuery = q.Encode()
+
+	log.Debug("listing tree", "url", u.String(), "ref", ref)
+	resp, err := http.Get(u.String())
+	if err != nil {
+		log.Error("error listing tree", "error", err)
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error listing tree: %s", resp.Status)
+	}
+	var result struct {
+		Tree []struct {
+			Path string `json:"path"`
+			Type string `json:"type"`
+		} `json:"tree"`
+		Truncated bool `json:"truncated"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		log.Error("error unmarshalling", "error", err)
+		return nil, err
+	}
+	if result.Truncated {
+		log.Error("tree is truncated", "repo", repo.FullName, "ref", rev)
+		return nil, fmt.Errorf("listing %s: %w", repo.FullName, discovery.ErrTruncated)
+	}
+
+	files := make([]string, 0, len(result.Tree))
+	for _, e := range result.Tree {
+		if e.Type == "blob" {
+			files = append(files, e.Path)
+		}
+	}
+	return files, nil
+}
+
+func (c *Client) GetPullRequestDiff(pr *vcs.PullRequest) ([]byte, error) {
 	u, err := c.parseURL(pr.URL)
 	if err != nil {
 		log.Error("error parsing URL", "error", err)
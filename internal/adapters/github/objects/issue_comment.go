@@ -18,9 +18,11 @@ type Issue struct {
 
 // Comment holds the comment from the IssueComment.
 type Comment struct {
+	URL       string    `json:"url"`
 	Body      string    `json:"body"`
 	NodeID    string    `json:"node_id"`
 	ID        uint64    `json:"id"`
+	User      User      `json:"user"`
 	Reactions Reactions `json:"reactions"`
 }
 
@@ -36,6 +38,7 @@ type Repository struct {
 	URL           string `json:"url"`
 	FullName      string `json:"full_name"`
 	StatusesURL   string `json:"statuses_url"`
+	TreesURL      string `json:"trees_url"`
 	DefaultBranch string `json:"default_branch"`
 }
 
@@ -1,19 +1,24 @@
 package objects
 
+import "github.com/ivanvc/turnip/internal/vcs"
+
 // PullRequestWebhook holds the pull request webhook GitHub resource.
 type PullRequestWebhook struct {
 	Action      string `json:"action"`
 	PullRequest `json:"pull_request"`
+	Sender      User `json:"sender"`
 }
 
 // PullRequest holds the pull request GitHub resource.
 type PullRequest struct {
 	URL         string `json:"url"`
 	CommentsURL string `json:"comments_url"`
+	Number      int    `json:"number"`
+	User        User   `json:"user"`
 
-	State string    `json:"state",omitempty`
-	Head  BranchRef `json:"head",omitempty`
-	Base  BranchRef `json:"base",omitempty`
+	State string    `json:"state,omitempty"`
+	Head  BranchRef `json:"head,omitempty"`
+	Base  BranchRef `json:"base,omitempty"`
 }
 
 // BranchRef holds the reference to a branch
@@ -21,5 +26,67 @@ type BranchRef struct {
 	Ref string `json:"ref"`
 	SHA string `json:"sha"`
 
-	Repository `json:"repo",omitempty`
+	Repository `json:"repo,omitempty"`
+}
+
+// Review holds the pull request review GitHub resource.
+type Review struct {
+	User     User   `json:"user"`
+	State    string `json:"state"`
+	CommitID string `json:"commit_id"`
+	// AuthorAssociation is the reviewer's relation to the repository, e.g.
+	// OWNER, MEMBER, COLLABORATOR or NONE.
+	AuthorAssociation string `json:"author_association"`
+}
+
+// IsApproved returns whether the reviews approve the commit. The last review
+// of every user with write access counts, and none of them can be requesting
+// changes. Reviews from anyone else, e.g. on a public repository, are ignored.
+func IsApproved(reviews []Review, sha string) bool {
+	last := make(map[string]Review)
+	for _, r := range reviews {
+		if r.State == "COMMENTED" || r.State == "PENDING" {
+			continue
+		}
+		switch r.AuthorAssociation {
+		case "OWNER", "MEMBER", "COLLABORATOR":
+		default:
+			continue
+		}
+		last[r.User.Login] = r
+	}
+
+	approved := false
+	for _, r := range last {
+		switch {
+		case r.State == "CHANGES_REQUESTED":
+			return false
+		case r.State == "APPROVED" && r.CommitID == sha:
+			approved = true
+		}
+	}
+	return approved
+}
+
+// User holds the GitHub user resource.
+type User struct {
+	Login string `json:"login"`
+}
+
+// ToVCS returns the pull request as a vcs.PullRequest.
+func (pr PullRequest) ToVCS() *vcs.PullRequest {
+	return &vcs.PullRequest{
+		URL:         pr.URL,
+		Number:      pr.Number,
+		CloneURL:    pr.Base.Repository.CloneURL,
+		StatusesURL: pr.Base.Repository.StatusesURL,
+		CommentsURL: pr.CommentsURL,
+		Head:        pr.Head.ToVCS(),
+		Base:        pr.Base.ToVCS(),
+	}
+}
+
+// ToVCS returns the branch as a vcs.Ref.
+func (b BranchRef) ToVCS() vcs.Ref {
+	return vcs.Ref{Ref: b.Ref, SHA: b.SHA, Repo: b.Repository.FullName, RepoURL: b.Repository.URL}
 }
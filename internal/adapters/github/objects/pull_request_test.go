@@ -0,0 +1,32 @@
+package objects
+
+import "testing"
+
+func TestIsApproved(t *testing.T) {
+	review := func(login, state, sha string) Review {
+		return Review{User: User{Login: login}, State: state, CommitID: sha, AuthorAssociation: "MEMBER"}
+	}
+
+	tt := []struct {
+		name     string
+		reviews  []Review
+		expected bool
+	}{
+		{"no reviews", nil, false},
+		{"approved", []Review{review("alice", "APPROVED", "head")}, true},
+		{"approved an older commit", []Review{review("alice", "APPROVED", "old")}, false},
+		{"comment after approval", []Review{review("alice", "APPROVED", "head"), review("alice", "COMMENTED", "head")}, true},
+		{"changes requested", []Review{review("alice", "APPROVED", "head"), review("bob", "CHANGES_REQUESTED", "head")}, false},
+		{"dismissed", []Review{review("alice", "APPROVED", "head"), review("alice", "DISMISSED", "head")}, false},
+		{"changes addressed", []Review{review("bob", "CHANGES_REQUESTED", "old"), review("bob", "APPROVED", "head")}, true},
+		{"approved by an outsider", []Review{{User: User{Login: "mallory"}, State: "APPROVED", CommitID: "head", AuthorAssociation: "NONE"}}, false},
+		{"approved by a contributor", []Review{{User: User{Login: "carol"}, State: "APPROVED", CommitID: "head", AuthorAssociation: "CONTRIBUTOR"}}, false},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := IsApproved(tc.reviews, "head"); actual != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
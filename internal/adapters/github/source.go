@@ -0,0 +1,41 @@
+package github
+
+import (
+	"github.com/ivanvc/turnip/internal/adapters/github/objects"
+	"github.com/ivanvc/turnip/internal/discovery"
+	"github.com/ivanvc/turnip/internal/vcs"
+)
+
+// Source gives access to the files of a repository at a given revision.
+type Source struct {
+	client *Client
+	repo   objects.Repository
+	ref    objects.BranchRef
+}
+
+// sourceCacheSize is the number of files fetched from the sources that are
+// kept in memory.
+const sourceCacheSize = 1000
+
+// NewSource returns a Source for the repository at the given revision. Its
+// files are cached by commit.
+func (c *Client) NewSource(ref vcs.Ref) discovery.Source {
+	repo := objects.Repository{
+		URL:         ref.RepoURL,
+		FullName:    ref.Repo,
+		ContentsURL: ref.RepoURL + "/contents/{+path}",
+		TreesURL:    ref.RepoURL + "/git/trees{/sha}",
+	}
+	src := &Source{client: c, repo: repo, ref: objects.BranchRef{Ref: ref.Ref, SHA: ref.SHA}}
+	return c.cache.Source(src, ref.Repo, ref.SHA)
+}
+
+// ListFiles conforms to the discovery.Source interface.
+func (s *Source) ListFiles() ([]string, error) {
+	return s.client.ListTree(s.repo, s.ref)
+}
+
+// FetchFile conforms to the discovery.Source interface.
+func (s *Source) FetchFile(path string) ([]byte, error) {
+	return s.client.FetchFile(path, s.repo, s.ref)
+}
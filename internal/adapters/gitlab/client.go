@@ -0,0 +1,449 @@
+package gitlab
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/ivanvc/turnip/internal/adapters/gitlab/objects"
+	"github.com/ivanvc/turnip/internal/config"
+	"github.com/ivanvc/turnip/internal/discovery"
+	"github.com/ivanvc/turnip/internal/vcs"
+)
+
+// maxDescriptionLength is the maximum length of a commit status description.
+const maxDescriptionLength = 255
+
+type statusRequest struct {
+	State       string `json:"state"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+type note struct {
+	ID        int          `json:"id"`
+	Body      string       `json:"body"`
+	System    bool         `json:"system"`
+	Author    objects.User `json:"author"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// approvedNote is the body of the system note GitLab adds when a merge
+// request is approved.
+const approvedNote = "approved this merge request"
+
+// mergeRequestVersion is a push to a merge request.
+type mergeRequestVersion struct {
+	HeadCommitSHA string    `json:"head_commit_sha"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type diffFile struct {
+	OldPath     string `json:"old_path"`
+	NewPath     string `json:"new_path"`
+	AMode       string `json:"a_mode"`
+	BMode       string `json:"b_mode"`
+	NewFile     bool   `json:"new_file"`
+	RenamedFile bool   `json:"renamed_file"`
+	DeletedFile bool   `json:"deleted_file"`
+	Diff        string `json:"diff"`
+}
+
+type treeEntry struct {
+	Path string `json:"path"`
+	Type string `json:"type"`
+}
+
+// Client calls the REST API (v4) of a GitLab instance. The URLs it returns,
+// and receives, are the API URLs of the projects and merge requests.
+type Client struct {
+	url   string
+	token string
+	cache *discovery.Cache
+}
+
+func NewClient(cfg *config.Config) *Client {
+	return &Client{strings.TrimSuffix(cfg.GitLabURL, "/"), cfg.GitLabToken, discovery.NewCache(sourceCacheSize)}
+}
+
+// URL returns the URL of the GitLab instance.
+func (c *Client) URL() string {
+	return c.url
+}
+
+// PullRequest returns the merge request, with the URLs of its project's API.
+// The statuses are set in the source project, which has the commits.
+func (c *Client) PullRequest(mr objects.MergeRequest) *vcs.PullRequest {
+	source, target := c.projectURL(mr.SourceProjectID), c.projectURL(mr.TargetProjectID)
+	u := fmt.Sprintf("%s/merge_requests/%d", target, mr.IID)
+	return &vcs.PullRequest{
+		URL:         u,
+		Number:      mr.IID,
+		CloneURL:    mr.Source.GitHTTPURL,
+		StatusesURL: source + "/statuses/{sha}",
+		CommentsURL: u + "/notes",
+		Head: vcs.Ref{
+			Ref:     mr.SourceBranch,
+			SHA:     mr.LastCommit.ID,
+			Repo:    mr.Source.PathWithNamespace,
+			RepoURL: source,
+		},
+		Base: vcs.Ref{
+			Ref:     mr.TargetBranch,
+			Repo:    mr.Target.PathWithNamespace,
+			RepoURL: target,
+		},
+	}
+}
+
+// CreateCheckRun sets the pending commit status, and returns its URL.
+// Updating a status is setting it again, with the same name. GitLab rejects
+// setting the state a status already has, so a pending status can't be
+// updated with another description.
+func (c *Client) CreateCheckRun(statusesURL, sha, name, description string) (string, error) {
+	checkURL := strings.Replace(statusesURL, "{sha}", sha, 1)
+	if err := c.setStatus(checkURL, name, "pending", description); err != nil {
+		return "", err
+	}
+	return checkURL, nil
+}
+
+func (c *Client) StartCheckRun(checkURL, checkName string) error {
+	return c.setStatus(checkURL, checkName, "running", "Turnip is running")
+}
+
+func (c *Client) FinishCheckRun(checkURL, checkName, conclusion string) error {
+	return c.setStatus(checkURL, checkName, statusState(conclusion), "Turnip has finished running")
+}
+
+func (c *Client) UpdateCheckRun(checkURL, checkName, state, description string) error {
+	return c.setStatus(checkURL, checkName, statusState(state), description)
+}
+
+// statusState returns the GitLab commit status state of the GitHub one.
+func statusState(state string) string {
+	switch state {
+	case "failure", "error":
+		return "failed"
+	}
+	return state
+}
+
+func (c *Client) setStatus(checkURL, name, state, description string) error {
+	if len(description) > maxDescriptionLength {
+		description = description[:maxDescriptionLength-3] + "..."
+	}
+	req := statusRequest{
+		State:       state,
+		Name:        name,
+		Description: description,
+	}
+	log.Debug("setting commit status", "url", checkURL, "status", req)
+	if _, err := c.do(http.MethodPost, checkURL, req, nil); err != nil {
+		log.Error("Error setting commit status", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (c *Client) CreateComment(commentsURL, body string) error {
+	req := struct {
+		Body string `json:"body"`
+	}{body}
+	if _, err := c.do(http.MethodPost, commentsURL, req, nil); err != nil {
+		log.Error("Error creating note", "error", err)
+		return err
+	}
+	return nil
+}
+
+// ListComments returns the notes of the merge request, oldest first. The
+// system notes, e.g. "added 1 commit", are skipped.
+func (c *Client) ListComments(commentsURL string) ([]vcs.Comment, error) {
+	notes, err := listAll[note](c, commentsURL, url.Values{"sort": {"asc"}, "order_by": {"created_at"}})
+	if err != nil {
+		log.Error("Error listing notes", "error", err)
+		return nil, err
+	}
+	comments := make([]vcs.Comment, 0, len(notes))
+	for _, n := range notes {
+		if n.System {
+			continue
+		}
+		comments = append(comments, vcs.Comment{URL: NoteURL(commentsURL, n.ID), Body: n.Body})
+	}
+	return comments, nil
+}
+
+func (c *Client) UpdateComment(commentURL, body string) error {
+	req := struct {
+		Body string `json:"body"`
+	}{body}
+	if _, err := c.do(http.MethodPut, commentURL, req, nil); err != nil {
+		log.Error("Error updating note", "error", err)
+		return err
+	}
+	return nil
+}
+
+// HeadSHA returns the head commit of the merge request of the notes URL.
+func (c *Client) HeadSHA(commentsURL string) (string, error) {
+	var mr struct {
+		SHA string `json:"sha"`
+	}
+	if _, err := c.do(http.MethodGet, strings.TrimSuffix(commentsURL, "/notes"), nil, &mr); err != nil {
+		log.Error("Error getting merge request", "error", err)
+		return "", err
+	}
+	return mr.SHA, nil
+}
+
+// MinimizeComment does nothing, GitLab can't hide notes.
+func (c *Client) MinimizeComment(comment vcs.Comment, classifier string) error {
+	return nil
+}
+
+// AwardEmoji reacts to the note with the emoji, e.g. thumbsup.
+func (c *Client) AwardEmoji(noteURL, name string) error {
+	req := struct {
+		Name string `json:"name"`
+	}{name}
+	if _, err := c.do(http.MethodPost, noteURL+"/award_emoji", req, nil); err != nil {
+		log.Error("Error awarding emoji", "error", err)
+		return err
+	}
+	return nil
+}
+
+// IsApproved returns whether someone other than its author approved the
+// merge request after its head commit was pushed. The approvals API doesn't
+// tell which commit was approved, and they are kept on push unless the
+// project resets them, so an approval only counts if its system note is newer
+// than the merge request version of the head commit.
+func (c *Client) IsApproved(pr *vcs.PullRequest) (bool, error) {
+	var mr struct {
+		SHA    string       `json:"sha"`
+		Author objects.User `json:"author"`
+	}
+	if _, err := c.do(http.MethodGet, pr.URL, nil, &mr); err != nil {
+		log.Error("Error getting merge request", "error", err)
+		return false, err
+	}
+	if mr.SHA != pr.Head.SHA {
+		log.Info("merge request was pushed to since the event", "pr", pr.URL, "head", pr.Head.SHA, "current", mr.SHA)
+		return false, nil
+	}
+
+	versions, err := listAll[mergeRequestVersion](c, pr.URL+"/versions", nil)
+	if err != nil {
+		log.Error("Error listing merge request versions", "error", err)
+		return false, err
+	}
+	var pushedAt time.Time
+	for _, v := range versions {
+		if v.HeadCommitSHA == pr.Head.SHA && v.CreatedAt.After(pushedAt) {
+			pushedAt = v.CreatedAt
+		}
+	}
+	if pushedAt.IsZero() {
+		log.Info("no merge request version for the head commit", "pr", pr.URL, "head", pr.Head.SHA)
+		return false, nil
+	}
+
+	var result struct {
+		ApprovedBy []struct {
+			User objects.User `json:"user"`
+		} `json:"approved_by"`
+	}
+	if _, err := c.do(http.MethodGet, pr.URL+"/approvals", nil, &result); err != nil {
+		log.Error("Error getting approvals", "error", err)
+		return false, err
+	}
+	approvers := make(map[int]bool)
+	for _, a := range result.ApprovedBy {
+		if a.User.ID != mr.Author.ID {
+			approvers[a.User.ID] = true
+		}
+	}
+	if len(approvers) == 0 {
+		return false, nil
+	}
+
+	notes, err := listAll[note](c, pr.CommentsURL, url.Values{"sort": {"asc"}, "order_by": {"created_at"}})
+	if err != nil {
+		log.Error("Error listing notes", "error", err)
+		return false, err
+	}
+	for _, n := range notes {
+		if n.System && n.Body == approvedNote && approvers[n.Author.ID] && n.CreatedAt.After(pushedAt) {
+			return true, nil
+		}
+	}
+	log.Info("approvals are older than the head commit", "pr", pr.URL, "head", pr.Head.SHA)
+	return false, nil
+}
+
+// GetPullRequestDiff returns the changes of the merge request as a git diff.
+func (c *Client) GetPullRequestDiff(pr *vcs.PullRequest) ([]byte, error) {
+	files, err := listAll[diffFile](c, pr.URL+"/diffs", nil)
+	if err != nil {
+		log.Error("error fetching diff", "error", err)
+		return nil, err
+	}
+	return formatDiff(files), nil
+}
+
+// formatDiff returns the files as a git diff. GitLab only returns their hunks,
+// the headers are built from the rest of their attributes.
+func formatDiff(files []diffFile) []byte {
+	var b bytes.Buffer
+	for _, f := range files {
+		fmt.Fprintf(&b, "diff --git a/%s b/%s\n", f.OldPath, f.NewPath)
+		oldName, newName := "a/"+f.OldPath, "b/"+f.NewPath
+		switch {
+		case f.NewFile:
+			fmt.Fprintf(&b, "new file mode %s\n", f.BMode)
+			oldName = "/dev/null"
+		case f.DeletedFile:
+			fmt.Fprintf(&b, "deleted file mode %s\n", f.AMode)
+			newName = "/dev/null"
+		case f.RenamedFile:
+			fmt.Fprintf(&b, "rename from %s\nrename to %s\n", f.OldPath, f.NewPath)
+		}
+		// Binary files, and the ones too large to show, have no hunks.
+		if !strings.HasPrefix(f.Diff, "@@") {
+			continue
+		}
+		fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+		b.WriteString(f.Diff)
+		if !strings.HasSuffix(f.Diff, "\n") {
+			b.WriteString("\n")
+		}
+	}
+	return b.Bytes()
+}
+
+// FetchFile returns the contents of the file at the revision.
+func (c *Client) FetchFile(path string, ref vcs.Ref) ([]byte, error) {
+	u := ref.RepoURL + "/repository/files/" + url.PathEscape(path) + "/raw?ref=" + url.QueryEscape(revision(ref))
+	log.Debug("fetching file", "url", u, "path", path, "ref", ref)
+	var data []byte
+	if _, err := c.do(http.MethodGet, u, nil, &data); err != nil {
+		log.Error("error fetching file", "error", err)
+		return nil, err
+	}
+	return data, nil
+}
+
+// ListTree returns the paths of the files in the repository at the revision.
+func (c *Client) ListTree(ref vcs.Ref) ([]string, error) {
+	entries, err := listAll[treeEntry](c, ref.RepoURL+"/repository/tree", url.Values{"recursive": {"true"}, "ref": {revision(ref)}})
+	if err != nil {
+		log.Error("error listing tree", "error", err)
+		return nil, err
+	}
+	files := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.Type == "blob" {
+			files = append(files, e.Path)
+		}
+	}
+	return files, nil
+}
+
+// revision returns the commit of the ref, or its name if it's not known.
+func revision(ref vcs.Ref) string {
+	if ref.SHA != "" {
+		return ref.SHA
+	}
+	return ref.Ref
+}
+
+func (c *Client) projectURL(id int) string {
+	return fmt.Sprintf("%s/api/v4/projects/%d", c.url, id)
+}
+
+// listAll returns the items of every page, following the X-Next-Page header.
+func listAll[T any](c *Client, rawURL string, query url.Values) ([]T, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	for k, v := range query {
+		q[k] = v
+	}
+	q.Set("per_page", "100")
+
+	items := make([]T, 0)
+	for page := "1"; page != ""; {
+		q.Set("page", page)
+		u.RawQuery = q.Encode()
+		var result []T
+		header, err := c.do(http.MethodGet, u.String(), nil, &result)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, result...)
+		page = header.Get("X-Next-Page")
+	}
+	return items, nil
+}
+
+// do sends the request, with in as its JSON body, and decodes the JSON
+// response into out. If out is a *[]byte, it's set to the raw response.
+func (c *Client) do(method, u string, in, out any) (http.Header, error) {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("PRIVATE-TOKEN", c.token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("%s %s: %s: %s", method, req.URL.Path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	switch out := out.(type) {
+	case nil:
+	case *[]byte:
+		if *out, err = io.ReadAll(resp.Body); err != nil {
+			return nil, err
+		}
+	default:
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return nil, err
+		}
+	}
+	return resp.Header, nil
+}
+
+// NoteURL returns the API URL of the note, from the URL of the merge
+// request's notes.
+func NoteURL(commentsURL string, id int) string {
+	return commentsURL + "/" + strconv.Itoa(id)
+}
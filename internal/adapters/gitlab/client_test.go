@@ -0,0 +1,267 @@
+package gitlab
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bluekeyes/go-gitdiff/gitdiff"
+
+	"github.com/ivanvc/turnip/internal/adapters/gitlab/objects"
+	"github.com/ivanvc/turnip/internal/config"
+	"github.com/ivanvc/turnip/internal/vcs"
+)
+
+// newFakeGitLab returns a client of a fake GitLab serving the handlers, by
+// method and escaped path, and the requests it received.
+func newFakeGitLab(t *testing.T, handlers map[string]http.HandlerFunc) (*Client, *[]string) {
+	t.Helper()
+	requests := make([]string, 0)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "glpat-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		key := r.Method + " " + r.URL.EscapedPath()
+		requests = append(requests, strings.TrimSpace(key+" "+string(body)))
+		h, ok := handlers[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		h(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return NewClient(&config.Config{GitLabURL: srv.URL + "/", GitLabToken: "glpat-token"}), &requests
+}
+
+// paginated serves the pages of items, linking them with X-Next-Page.
+func paginated(pages ...any) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		page := 1
+		if p := r.URL.Query().Get("page"); p == "2" {
+			page = 2
+		}
+		if page < len(pages) {
+			w.Header().Set("X-Next-Page", "2")
+		}
+		json.NewEncoder(w).Encode(pages[page-1])
+	}
+}
+
+var mergeRequest = objects.MergeRequest{
+	IID:             7,
+	SourceBranch:    "feature",
+	TargetBranch:    "main",
+	SourceProjectID: 42,
+	TargetProjectID: 42,
+	LastCommit:      objects.Commit{ID: "abc123"},
+	Source:          objects.Project{ID: 42, PathWithNamespace: "infra/live", GitHTTPURL: "https://gitlab.example.com/infra/live.git"},
+	Target:          objects.Project{ID: 42, PathWithNamespace: "infra/live", GitHTTPURL: "https://gitlab.example.com/infra/live.git"},
+}
+
+func TestGetPullRequestDiff(t *testing.T) {
+	c, _ := newFakeGitLab(t, map[string]http.HandlerFunc{
+		"GET /api/v4/projects/42/merge_requests/7/diffs": paginated(
+			[]diffFile{
+				{OldPath: "infra/main.tf", NewPath: "infra/main.tf", AMode: "100644", BMode: "100644", Diff: "@@ -1 +1 @@\n-a\n+b\n"},
+				{OldPath: "infra/new.tf", NewPath: "infra/new.tf", AMode: "0", BMode: "100644", NewFile: true, Diff: "@@ -0,0 +1 @@\n+c\n"},
+			},
+			[]diffFile{
+				{OldPath: "old.tf", NewPath: "old.tf", AMode: "100644", BMode: "0", DeletedFile: true, Diff: "@@ -1 +0,0 @@\n-d\n"},
+				{OldPath: "a.tf", NewPath: "b.tf", AMode: "100644", BMode: "100644", RenamedFile: true},
+			},
+		),
+	})
+
+	diff, err := c.GetPullRequestDiff(c.PullRequest(mergeRequest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, _, err := gitdiff.Parse(bytes.NewReader(diff))
+	if err != nil {
+		t.Fatalf("error parsing diff: %v\n%s", err, diff)
+	}
+
+	type file struct {
+		oldName, newName        string
+		isNew, isDelete, rename bool
+		fragments               int
+	}
+	got := make([]file, 0, len(files))
+	for _, f := range files {
+		got = append(got, file{f.OldName, f.NewName, f.IsNew, f.IsDelete, f.IsRename, len(f.TextFragments)})
+	}
+	expected := []file{
+		{"infra/main.tf", "infra/main.tf", false, false, false, 1},
+		{"", "infra/new.tf", true, false, false, 1},
+		{"old.tf", "", false, true, false, 1},
+		{"a.tf", "b.tf", false, false, true, 0},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+}
+
+func TestSource(t *testing.T) {
+	c, _ := newFakeGitLab(t, map[string]http.HandlerFunc{
+		"GET /api/v4/projects/42/repository/tree": paginated(
+			[]treeEntry{{Path: "infra", Type: "tree"}, {Path: "turnip.yaml", Type: "blob"}},
+			[]treeEntry{{Path: "infra/main.tf", Type: "blob"}},
+		),
+		"GET /api/v4/projects/42/repository/files/infra%2Fturnip.yaml/raw": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("version: v1beta1\n"))
+		},
+	})
+	src := c.NewSource(c.PullRequest(mergeRequest).Head)
+
+	files, err := src.ListFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"turnip.yaml", "infra/main.tf"}; !reflect.DeepEqual(files, expected) {
+		t.Errorf("expected files %v, got %v", expected, files)
+	}
+
+	data, err := src.FetchFile("infra/turnip.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "version: v1beta1\n" {
+		t.Errorf("unexpected file contents %q", data)
+	}
+	if _, err := src.FetchFile("missing.yaml"); err == nil {
+		t.Error("expected an error fetching a missing file")
+	}
+}
+
+func TestStatuses(t *testing.T) {
+	c, requests := newFakeGitLab(t, map[string]http.HandlerFunc{
+		"POST /api/v4/projects/42/statuses/abc123": func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+		},
+	})
+	pr := c.PullRequest(mergeRequest)
+
+	checkURL, err := c.CreateCheckRun(pr.StatusesURL, pr.Head.SHA, "turnip/plot", "Queued")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.StartCheckRun(checkURL, "turnip/plot"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.FinishCheckRun(checkURL, "turnip/plot", "failure"); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		`POST /api/v4/projects/42/statuses/abc123 {"state":"pending","name":"turnip/plot","description":"Queued"}`,
+		`POST /api/v4/projects/42/statuses/abc123 {"state":"running","name":"turnip/plot","description":"Turnip is running"}`,
+		`POST /api/v4/projects/42/statuses/abc123 {"state":"failed","name":"turnip/plot","description":"Turnip has finished running"}`,
+	}
+	if !reflect.DeepEqual(*requests, expected) {
+		t.Errorf("expected requests %v, got %v", expected, *requests)
+	}
+}
+
+func TestComments(t *testing.T) {
+	c, requests := newFakeGitLab(t, map[string]http.HandlerFunc{
+		"GET /api/v4/projects/42/merge_requests/7/notes": paginated(
+			[]note{{ID: 1, Body: "summary"}, {ID: 2, Body: "added 1 commit", System: true}},
+			[]note{{ID: 3, Body: "/turnip plot"}},
+		),
+		"PUT /api/v4/projects/42/merge_requests/7/notes/1": func(w http.ResponseWriter, r *http.Request) {},
+	})
+	pr := c.PullRequest(mergeRequest)
+
+	comments, err := c.ListComments(pr.CommentsURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []vcs.Comment{
+		{URL: pr.CommentsURL + "/1", Body: "summary"},
+		{URL: pr.CommentsURL + "/3", Body: "/turnip plot"},
+	}
+	if !reflect.DeepEqual(comments, expected) {
+		t.Errorf("expected comments %v, got %v", expected, comments)
+	}
+
+	if err := c.UpdateComment(comments[0].URL, "updated"); err != nil {
+		t.Fatal(err)
+	}
+	if last := (*requests)[len(*requests)-1]; last != `PUT /api/v4/projects/42/merge_requests/7/notes/1 {"body":"updated"}` {
+		t.Errorf("unexpected update request %s", last)
+	}
+	if err := c.CreateComment(pr.CommentsURL, "new"); err == nil {
+		t.Error("expected an error from the unhandled request")
+	}
+}
+
+func TestIsApproved(t *testing.T) {
+	// The head commit abc123 was pushed at 10:00, after 9f8e7d6 at 09:00.
+	versions := []map[string]any{
+		{"id": 2, "head_commit_sha": "abc123", "base_commit_sha": "0a1b2c3", "start_commit_sha": "0a1b2c3", "created_at": "2026-10-19T10:00:00.000Z", "merge_request_id": 107, "state": "collected", "real_size": "1"},
+		{"id": 1, "head_commit_sha": "9f8e7d6", "base_commit_sha": "0a1b2c3", "start_commit_sha": "0a1b2c3", "created_at": "2026-10-19T09:00:00.000Z", "merge_request_id": 107, "state": "collected", "real_size": "1"},
+	}
+	tt := []struct {
+		name, mrSHA string
+		approvers   []int
+		approvedAt  map[int]string
+		expected    bool
+	}{
+		{"approved", "abc123", []int{2}, map[int]string{2: "2026-10-19T10:30:00.000Z"}, true},
+		{"not approved", "abc123", nil, nil, false},
+		{"approved by the author", "abc123", []int{1}, map[int]string{1: "2026-10-19T10:30:00.000Z"}, false},
+		{"pushed to since", "def456", []int{2}, map[int]string{2: "2026-10-19T10:30:00.000Z"}, false},
+		{"approved an older commit", "abc123", []int{2}, map[int]string{2: "2026-10-19T09:30:00.000Z"}, false},
+		{"approval revoked", "abc123", []int{3}, map[int]string{2: "2026-10-19T10:30:00.000Z", 3: "2026-10-19T09:30:00.000Z"}, false},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			approvedBy := make([]map[string]any, 0)
+			for _, id := range tc.approvers {
+				approvedBy = append(approvedBy, map[string]any{"user": map[string]any{"id": id, "username": fmt.Sprintf("user%d", id)}})
+			}
+			notes := []map[string]any{
+				{"id": 1, "body": "added 1 commit", "author": map[string]any{"id": 1}, "created_at": "2026-10-19T10:00:00.000Z", "system": true, "noteable_type": "MergeRequest"},
+			}
+			for id, at := range tc.approvedAt {
+				notes = append(notes, map[string]any{"id": 10 + id, "body": "approved this merge request", "author": map[string]any{"id": id}, "created_at": at, "system": true, "noteable_type": "MergeRequest"})
+			}
+			c, _ := newFakeGitLab(t, map[string]http.HandlerFunc{
+				"GET /api/v4/projects/42/merge_requests/7": func(w http.ResponseWriter, r *http.Request) {
+					json.NewEncoder(w).Encode(map[string]any{"sha": tc.mrSHA, "author": map[string]any{"id": 1}})
+				},
+				"GET /api/v4/projects/42/merge_requests/7/versions": paginated(versions),
+				"GET /api/v4/projects/42/merge_requests/7/approvals": func(w http.ResponseWriter, r *http.Request) {
+					json.NewEncoder(w).Encode(map[string]any{
+						"id":                 107,
+						"iid":                7,
+						"project_id":         42,
+						"approved":           len(tc.approvers) > 0,
+						"approvals_required": 1,
+						"approvals_left":     0,
+						"approved_by":        approvedBy,
+					})
+				},
+				"GET /api/v4/projects/42/merge_requests/7/notes": paginated(notes),
+			})
+
+			approved, err := c.IsApproved(c.PullRequest(mergeRequest))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if approved != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, approved)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"github.com/ivanvc/turnip/internal/adapters/gitlab/objects"
+	"github.com/ivanvc/turnip/internal/common"
+	"github.com/ivanvc/turnip/internal/pullrequest"
+)
+
+// HandleMergeRequest plots the projects when the merge request is opened, or
+// its source branch is pushed to.
+func HandleMergeRequest(common *common.Common, payload *objects.MergeRequestWebhook) error {
+	mr := payload.ObjectAttributes
+	// Updates without oldrev change the merge request's title, labels, etc.
+	if mr.Action != "open" && (mr.Action != "update" || mr.OldRev == "") {
+		return nil
+	}
+	if !pullrequest.IsRepoAllowed(common, mr.Target.PathWithNamespace) {
+		return nil
+	}
+
+	return pullrequest.Opened(common, common.GitLabClient, common.GitLabClient.PullRequest(mr), payload.User.Username)
+}
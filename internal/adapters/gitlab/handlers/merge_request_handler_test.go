@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ivanvc/turnip/internal/adapters/gitlab"
+	"github.com/ivanvc/turnip/internal/adapters/gitlab/objects"
+	"github.com/ivanvc/turnip/internal/common"
+	"github.com/ivanvc/turnip/internal/config"
+	"github.com/ivanvc/turnip/internal/scheduler"
+	"github.com/ivanvc/turnip/internal/yaml"
+)
+
+const turnipYAML = `version: v1beta1
+workflows:
+  tf:
+    adapter:
+      type: terraform
+      version: 1.9.0
+projects:
+  - dir: infra/network
+    autoPlot: true
+    workflow: tf
+  - dir: infra/app
+    autoPlot: true
+    workflow: tf
+`
+
+// dependentYAML has infra/app waiting for infra/network.
+const dependentYAML = `version: v1beta1
+workflows:
+  tf:
+    adapter:
+      type: terraform
+      version: 1.9.0
+projects:
+  - dir: infra/network
+    autoPlot: true
+    workflow: tf
+  - dir: infra/app
+    autoPlot: true
+    workflow: tf
+    dependsOn: [infra/network]
+`
+
+const networkDiff = `[{"old_path": "infra/network/main.tf", "new_path": "infra/network/main.tf", "a_mode": "100644", "b_mode": "100644", "diff": "@@ -1 +1 @@\n-a\n+b\n"}]`
+
+const networkAndAppDiff = `[
+  {"old_path": "infra/network/main.tf", "new_path": "infra/network/main.tf", "a_mode": "100644", "b_mode": "100644", "diff": "@@ -1 +1 @@\n-a\n+b\n"},
+  {"old_path": "infra/app/main.tf", "new_path": "infra/app/main.tf", "a_mode": "100644", "b_mode": "100644", "diff": "@@ -1 +1 @@\n-a\n+b\n"}
+]`
+
+const mergeRequestHook = `{
+  "object_kind": "merge_request",
+  "user": {"username": "jdoe"},
+  "project": {"id": 42, "path_with_namespace": "infra/live"},
+  "object_attributes": {
+    "iid": 7,
+    "action": %q,
+    "oldrev": %q,
+    "source_branch": "feature",
+    "target_branch": "main",
+    "source_project_id": 42,
+    "target_project_id": 42,
+    "last_commit": {"id": "abc123"},
+    "source": {"id": 42, "path_with_namespace": "infra/live", "git_http_url": "https://gitlab.example.com/infra/live.git"},
+    "target": {"id": 42, "path_with_namespace": "infra/live", "git_http_url": "https://gitlab.example.com/infra/live.git"}
+  }
+}`
+
+type job struct {
+	command, cloneURL, headRef, headSHA, repo, checkName, project string
+}
+
+type fakeExecutor struct {
+	mu   sync.Mutex
+	jobs []job
+}
+
+func (e *fakeExecutor) CreateJob(command, cloneURL, headRef, headSHA, repoFullName, checkURL, checkName, commentsURL, extraArgs string, project *yaml.Project) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.jobs = append(e.jobs, job{command, cloneURL, headRef, headSHA, repoFullName, checkName, project.Dir})
+	return nil
+}
+
+// newFakeGitLab serves the repository's turnip.yaml, and the merge request
+// diff, recording the commit statuses set. Like GitLab, it rejects setting a
+// status to the state it already has.
+func newFakeGitLab(t *testing.T, turnipYAML, diff string) (*httptest.Server, *[]string) {
+	t.Helper()
+	var mu sync.Mutex
+	statuses := make([]string, 0)
+	states := make(map[string]string)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.EscapedPath() {
+		case "GET /api/v4/projects/42/repository/files/turnip.yaml/raw":
+			io.WriteString(w, turnipYAML)
+		case "GET /api/v4/projects/42/repository/tree":
+			io.WriteString(w, `[{"path": "turnip.yaml", "type": "blob"}, {"path": "infra/network/main.tf", "type": "blob"}, {"path": "infra/app/main.tf", "type": "blob"}]`)
+		case "GET /api/v4/projects/42/merge_requests/7/diffs":
+			io.WriteString(w, diff)
+		case "POST /api/v4/projects/42/statuses/abc123":
+			var status struct {
+				State       string `json:"state"`
+				Name        string `json:"name"`
+				Description string `json:"description"`
+			}
+			json.NewDecoder(r.Body).Decode(&status)
+			mu.Lock()
+			defer mu.Unlock()
+			if states[status.Name] == status.State {
+				t.Errorf("status %s set to %s twice", status.Name, status.State)
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprintf(w, `{"message": "Cannot transition status via :enqueue from :%s"}`, status.State)
+				return
+			}
+			states[status.Name] = status.State
+			statuses = append(statuses, status.Name+" "+status.State+" "+status.Description)
+			w.WriteHeader(http.StatusCreated)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &statuses
+}
+
+func TestHandleMergeRequest(t *testing.T) {
+	tt := []struct {
+		name, action, oldrev string
+		expected             []job
+	}{
+		{"open", "open", "", []job{
+			{"plot", "https://gitlab.example.com/infra/live.git", "feature", "abc123", "infra/live", "turnip/terraform/plan/infra/network/", "infra/network"},
+		}},
+		{"push", "update", "def456", []job{
+			{"plot", "https://gitlab.example.com/infra/live.git", "feature", "abc123", "infra/live", "turnip/terraform/plan/infra/network/", "infra/network"},
+		}},
+		{"title change", "update", "", nil},
+		{"close", "close", "", nil},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			srv, statuses := newFakeGitLab(t, turnipYAML, networkDiff)
+			cfg := &config.Config{GitLabURL: srv.URL, GitLabToken: "glpat-token"}
+			executor := new(fakeExecutor)
+			c := &common.Common{
+				Config:       cfg,
+				Executor:     executor,
+				GitLabClient: gitlab.NewClient(cfg),
+				Scheduler:    scheduler.New(0),
+			}
+
+			var payload objects.MergeRequestWebhook
+			body := strings.NewReader(fmt.Sprintf(mergeRequestHook, tc.action, tc.oldrev))
+			if err := json.NewDecoder(body).Decode(&payload); err != nil {
+				t.Fatal(err)
+			}
+			if err := HandleMergeRequest(c, &payload); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(executor.jobs, tc.expected) {
+				t.Errorf("expected jobs %+v, got %+v", tc.expected, executor.jobs)
+			}
+			if len(*statuses) != len(tc.expected) {
+				t.Errorf("expected a pending status per job, got %v", *statuses)
+			}
+		})
+	}
+}
+
+func TestHandleMergeRequestDependencies(t *testing.T) {
+	srv, statuses := newFakeGitLab(t, dependentYAML, networkAndAppDiff)
+	cfg := &config.Config{GitLabURL: srv.URL, GitLabToken: "glpat-token"}
+	executor := new(fakeExecutor)
+	c := &common.Common{
+		Config:       cfg,
+		Executor:     executor,
+		GitLabClient: gitlab.NewClient(cfg),
+		Scheduler:    scheduler.New(0),
+	}
+
+	var payload objects.MergeRequestWebhook
+	body := strings.NewReader(fmt.Sprintf(mergeRequestHook, "open", ""))
+	if err := json.NewDecoder(body).Decode(&payload); err != nil {
+		t.Fatal(err)
+	}
+	if err := HandleMergeRequest(c, &payload); err != nil {
+		t.Fatal(err)
+	}
+
+	// infra/app waits for infra/network to finish.
+	expectedJobs := []job{
+		{"plot", "https://gitlab.example.com/infra/live.git", "feature", "abc123", "infra/live", "turnip/terraform/plan/infra/network/", "infra/network"},
+	}
+	if !reflect.DeepEqual(executor.jobs, expectedJobs) {
+		t.Errorf("expected jobs %+v, got %+v", expectedJobs, executor.jobs)
+	}
+	expectedStatuses := []string{
+		"turnip/terraform/plan/infra/network/ pending Queued",
+		"turnip/terraform/plan/infra/app/ pending Waiting for turnip/terraform/plan/infra/network/",
+	}
+	sort.Strings(*statuses)
+	sort.Strings(expectedStatuses)
+	if !reflect.DeepEqual(*statuses, expectedStatuses) {
+		t.Errorf("expected statuses %q, got %q", expectedStatuses, *statuses)
+	}
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/ivanvc/turnip/internal/adapters/gitlab"
+	"github.com/ivanvc/turnip/internal/adapters/gitlab/objects"
+	"github.com/ivanvc/turnip/internal/common"
+	"github.com/ivanvc/turnip/internal/pullrequest"
+)
+
+// HandleNote runs the /turnip command of a merge request note.
+func HandleNote(common *common.Common, payload *objects.NoteWebhook) error {
+	note := payload.ObjectAttributes
+	if note.NoteableType != "MergeRequest" || payload.MergeRequest == nil {
+		return nil
+	}
+	if !strings.HasPrefix(note.Body, "/turnip") {
+		return nil
+	}
+	if !pullrequest.IsRepoAllowed(common, payload.Project.PathWithNamespace) {
+		return nil
+	}
+
+	pr := common.GitLabClient.PullRequest(*payload.MergeRequest)
+	emoji := "thumbsup"
+	err := pullrequest.RunCommand(common, common.GitLabClient, pr, payload.User.Username, note.Body)
+	if err != nil {
+		emoji = "confused"
+	}
+	if aerr := common.GitLabClient.AwardEmoji(gitlab.NoteURL(pr.CommentsURL, note.ID), emoji); aerr != nil {
+		log.Error("Error awarding emoji", "error", aerr)
+	}
+	return err
+}
@@ -0,0 +1,46 @@
+package objects
+
+// MergeRequestWebhook holds the merge request webhook GitLab resource.
+type MergeRequestWebhook struct {
+	User             User         `json:"user"`
+	Project          Project      `json:"project"`
+	ObjectAttributes MergeRequest `json:"object_attributes"`
+}
+
+// MergeRequest holds the merge request GitLab resource, as sent in the
+// webhooks.
+type MergeRequest struct {
+	IID             int     `json:"iid"`
+	URL             string  `json:"url"`
+	SourceBranch    string  `json:"source_branch"`
+	TargetBranch    string  `json:"target_branch"`
+	SourceProjectID int     `json:"source_project_id"`
+	TargetProjectID int     `json:"target_project_id"`
+	LastCommit      Commit  `json:"last_commit"`
+	Source          Project `json:"source"`
+	Target          Project `json:"target"`
+
+	// Action is only set in the merge request webhook, e.g. open or update.
+	Action string `json:"action,omitempty"`
+	// OldRev is set in the update webhook when the source branch was pushed
+	// to.
+	OldRev string `json:"oldrev,omitempty"`
+}
+
+// Project holds the project GitLab resource.
+type Project struct {
+	ID                int    `json:"id"`
+	PathWithNamespace string `json:"path_with_namespace"`
+	GitHTTPURL        string `json:"git_http_url"`
+}
+
+// Commit holds the commit GitLab resource.
+type Commit struct {
+	ID string `json:"id"`
+}
+
+// User holds the user GitLab resource.
+type User struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+}
@@ -0,0 +1,17 @@
+package objects
+
+// NoteWebhook holds the note (comment) webhook GitLab resource.
+type NoteWebhook struct {
+	User             User          `json:"user"`
+	Project          Project       `json:"project"`
+	ObjectAttributes Note          `json:"object_attributes"`
+	MergeRequest     *MergeRequest `json:"merge_request,omitempty"`
+}
+
+// Note holds the note GitLab resource.
+type Note struct {
+	ID           int    `json:"id"`
+	Body         string `json:"note"`
+	NoteableType string `json:"noteable_type"`
+	System       bool   `json:"system"`
+}
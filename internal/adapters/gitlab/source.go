@@ -0,0 +1,32 @@
+package gitlab
+
+import (
+	"github.com/ivanvc/turnip/internal/discovery"
+	"github.com/ivanvc/turnip/internal/vcs"
+)
+
+// Source gives access to the files of a project at a given revision.
+type Source struct {
+	client *Client
+	ref    vcs.Ref
+}
+
+// sourceCacheSize is the number of files fetched from the sources that are
+// kept in memory.
+const sourceCacheSize = 1000
+
+// NewSource returns a Source for the project at the given revision. Its files
+// are cached by commit.
+func (c *Client) NewSource(ref vcs.Ref) discovery.Source {
+	return c.cache.Source(&Source{client: c, ref: ref}, ref.RepoURL, ref.SHA)
+}
+
+// ListFiles conforms to the discovery.Source interface.
+func (s *Source) ListFiles() ([]string, error) {
+	return s.client.ListTree(s.ref)
+}
+
+// FetchFile conforms to the discovery.Source interface.
+func (s *Source) FetchFile(path string) ([]byte, error) {
+	return s.client.FetchFile(path, s.ref)
+}
@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"os"
+
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+// NewRootCmd returns the turnip command line interface. Without a command,
+// turnip runs the server.
+func NewRootCmd() *cobra.Command {
+	var logLevel string
+	root := &cobra.Command{
+		Use:               "turnip",
+		Short:             "Turnip is an IaC automation bot",
+		SilenceUsage:      true,
+		CompletionOptions: cobra.CompletionOptions{DisableDefaultCmd: true},
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			log.Default().SetLevel(log.ParseLevel(logLevel))
+		},
+	}
+	// The commands report their errors, the logs are for debugging.
+	root.PersistentFlags().StringVar(&logLevel, "log-level", "fatal", "the level of the logs, e.g. debug")
+	root.AddCommand(newConfigCmd())
+	root.AddCommand(newSimulateCmd())
+	root.AddCommand(newRunCmd())
+	return root
+}
+
+// IsCommand returns whether the arguments run a command of the command line
+// interface, rather than the server.
+func IsCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	cmd, _, err := NewRootCmd().Find(args)
+	return err == nil && cmd.HasParent()
+}
+
+// Execute runs the command line interface with the process' arguments.
+func Execute() error {
+	root := NewRootCmd()
+	root.SetArgs(os.Args[1:])
+	return root.Execute()
+}
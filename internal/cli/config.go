@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ivanvc/turnip/internal/discovery"
+	"github.com/ivanvc/turnip/internal/yaml"
+)
+
+func newConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Work with turnip.yaml files",
+	}
+	cmd.AddCommand(newConfigSchemaCmd())
+	cmd.AddCommand(newConfigMigrateCmd())
+	cmd.AddCommand(newConfigValidateCmd())
+	return cmd
+}
+
+func newConfigSchemaCmd() *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON Schema of turnip.yaml",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			schema, err := yaml.Schema()
+			if err != nil {
+				return err
+			}
+			if output != "" {
+				return os.WriteFile(output, schema, 0644)
+			}
+			_, err = cmd.OutOrStdout().Write(schema)
+			return err
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "", "the file to write the schema to, instead of stdout")
+	return cmd
+}
+
+func newConfigMigrateCmd() *cobra.Command {
+	var dryRun bool
+	cmd := &cobra.Command{
+		Use:   "migrate [dir]",
+		Short: "Rewrite the turnip.yaml of the repository in dir, and the files it includes, as " + yaml.V1beta1,
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir := "."
+			if len(args) > 0 {
+				dir = args[0]
+			}
+			src := discovery.DirSource(dir)
+			root, err := src.FetchFile(discovery.ConfigFile)
+			if err != nil {
+				return err
+			}
+			m, err := yaml.NewMigration(root)
+			if err != nil {
+				return err
+			}
+			files, err := discovery.IncludedFiles(m.Include, src)
+			if err != nil {
+				return err
+			}
+
+			// Migrate every file before writing any.
+			migrated := make(map[string][]byte)
+			for _, file := range append([]string{discovery.ConfigFile}, files...) {
+				data, err := src.FetchFile(file)
+				if err != nil {
+					return err
+				}
+				if migrated[file], err = m.Migrate(file, data); err != nil {
+					return err
+				}
+			}
+
+			for _, file := range append([]string{discovery.ConfigFile}, files...) {
+				if dryRun {
+					fmt.Fprintf(cmd.OutOrStdout(), "# %s\n%s", file, migrated[file])
+					continue
+				}
+				if err := os.WriteFile(filepath.Join(dir, filepath.FromSlash(file)), migrated[file], 0644); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "migrated %s\n", file)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the migrated files instead of writing them")
+	return cmd
+}
+
+func newConfigValidateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate [dir]",
+		Short: "Validate the turnip.yaml of the repository in dir, and the files it includes",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir := "."
+			if len(args) > 0 {
+				dir = args[0]
+			}
+			cfg, err := loadConfig(discovery.DirSource(dir), yaml.Policy{})
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "turnip.yaml is valid, %d projects\n", len(cfg.Projects))
+			return nil
+		},
+	}
+}
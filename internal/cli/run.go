@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ivanvc/turnip/internal/comment"
+	"github.com/ivanvc/turnip/internal/discovery"
+	"github.com/ivanvc/turnip/internal/job/commands"
+	jobplugin "github.com/ivanvc/turnip/internal/job/plugin"
+	"github.com/ivanvc/turnip/internal/job/redact"
+	"github.com/ivanvc/turnip/internal/plugin"
+	"github.com/ivanvc/turnip/internal/template"
+	"github.com/ivanvc/turnip/internal/yaml"
+	pb "github.com/ivanvc/turnip/pkg/turnip"
+)
+
+func newRunCmd() *cobra.Command {
+	var repoDir, dir, workspace, name, extraArgs string
+	var skipInstall bool
+	cmd := &cobra.Command{
+		Use:   "run plot|lift",
+		Short: "Run plot or lift for a project of the local checkout, as the runner would",
+		Long: `Run plot or lift for a project of the local checkout, as the runner would,
+and print the result as the pull request comment. The project and the extra
+arguments are rendered as templates, with the checkout's HEAD as the head
+commit. The tools are installed to a temporary directory, unless --skip-install
+is set to use the ones in the PATH.`,
+		ValidArgs: []string{"plot", "lift"},
+		Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root, err := filepath.Abs(repoDir)
+			if err != nil {
+				return err
+			}
+			cfg, err := loadConfig(discovery.DirSource(root), yaml.Policy{})
+			if err != nil {
+				return err
+			}
+			project, err := findProject(cfg, name, dir, workspace)
+			if err != nil {
+				return err
+			}
+			if skipInstall {
+				skipToolInstall(&project)
+			}
+			env, err := localEnvironment(root, args[0])
+			if err != nil {
+				return err
+			}
+			tpl := template.New(project, env)
+			if project, err = tpl.Render(project); err != nil {
+				return fmt.Errorf("error rendering project: %w", err)
+			}
+			if extraArgs, err = tpl.Execute(extraArgs); err != nil {
+				return fmt.Errorf("error rendering extra args: %w", err)
+			}
+
+			tmpDir, err := os.MkdirTemp("", "turnip-run-*")
+			if err != nil {
+				return err
+			}
+			defer os.RemoveAll(tmpDir)
+			jobplugin.BinDir = filepath.Join(tmpDir, "bin")
+			if err := os.Mkdir(jobplugin.BinDir, 0750); err != nil {
+				return err
+			}
+			os.Setenv("PATH", jobplugin.BinDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+			finishedWithError, output, err := commands.RunJob(tmpDir, root, args[0], project, extraArgs)
+			req := &pb.JobFinishedRequest{
+				Command:          args[0],
+				Adapter:          project.GetAdapterName(),
+				ProjectName:      project.Name,
+				ProjectDir:       project.Dir,
+				ProjectWorkspace: project.GetWorkspace(),
+				Status:           pb.JobStatus_SUCCEEDED,
+			}
+			if err != nil || finishedWithError {
+				req.Status = pb.JobStatus_FAILED
+			}
+			redactor, rerr := redact.New(nil, project.LoadedWorkflow.RedactPatterns)
+			if rerr != nil {
+				return fmt.Errorf("error loading redact patterns: %w", rerr)
+			}
+			req.Output = redactor.Redact(output)
+			if err != nil {
+				req.Error = redactor.RedactString(err.Error())
+			}
+
+			fmt.Fprintln(cmd.OutOrStdout(), comment.Render(req, comment.Options{Format: plugin.FormatOutput}))
+			if req.Status == pb.JobStatus_FAILED {
+				return fmt.Errorf("%s failed", args[0])
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&repoDir, "repo", ".", "the directory of the repository")
+	cmd.Flags().StringVarP(&dir, "dir", "d", "", "the directory of the project")
+	cmd.Flags().StringVarP(&workspace, "workspace", "w", "", "the workspace of the project")
+	cmd.Flags().StringVarP(&name, "project", "p", "", "the name of the project, instead of its dir and workspace")
+	cmd.Flags().StringVar(&extraArgs, "extra-args", "", "the extra arguments for the command")
+	cmd.Flags().BoolVar(&skipInstall, "skip-install", false, "use the tools in the PATH instead of installing them")
+	return cmd
+}
+
+// findProject returns the project with the name, or else in the dir and
+// workspace.
+func findProject(cfg yaml.Config, name, dir, workspace string) (yaml.Project, error) {
+	if name == "" && dir == "" {
+		return yaml.Project{}, errors.New("one of --project or --dir must be set")
+	}
+	for _, p := range cfg.Projects {
+		if name != "" && p.Name == name {
+			return p, nil
+		}
+		if name == "" && path.Clean(p.Dir) == path.Clean(dir) && p.GetWorkspace() == workspace {
+			return p, nil
+		}
+	}
+	if name != "" {
+		return yaml.Project{}, fmt.Errorf("project %s not found", name)
+	}
+	return yaml.Project{}, fmt.Errorf("project %s with workspace %q not found", dir, workspace)
+}
+
+// localEnvironment returns the templates' environment for the command in the
+// checkout at root. There is no pull request, so only its head is set.
+func localEnvironment(root, command string) (template.Environment, error) {
+	c := exec.Command("git", "-C", root, "rev-parse", "HEAD")
+	var stderr bytes.Buffer
+	c.Stderr = &stderr
+	out, err := c.Output()
+	if err != nil {
+		return template.Environment{}, fmt.Errorf("git rev-parse HEAD: %s", strings.TrimSpace(stderr.String()))
+	}
+	return template.Environment{
+		HeadSHA: strings.TrimSpace(string(out)),
+		Command: command,
+	}, nil
+}
+
+// skipToolInstall sets the project's adapter to skip installing its tool.
+func skipToolInstall(project *yaml.Project) {
+	if a, err := project.LoadedWorkflow.GetAdapter(); err == nil {
+		project.LoadedWorkflow.SetAdapter(a.WithoutInstall())
+	}
+}
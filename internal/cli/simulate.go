@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/bluekeyes/go-gitdiff/gitdiff"
+	"github.com/spf13/cobra"
+
+	"github.com/ivanvc/turnip/internal/config"
+	"github.com/ivanvc/turnip/internal/discovery"
+	"github.com/ivanvc/turnip/internal/plugin"
+	"github.com/ivanvc/turnip/internal/trigger"
+	"github.com/ivanvc/turnip/internal/yaml"
+)
+
+func newSimulateCmd() *cobra.Command {
+	var diffFile, gitRange, repoConfig, repoName string
+	cmd := &cobra.Command{
+		Use:   "simulate [dir]",
+		Short: "Print the projects a diff triggers, when plotting and auto plotting",
+		Long: `Print the projects a diff triggers, when plotting and auto plotting, and
+the rule that matched. The configuration is loaded from the repository checked
+out in dir, and the diff is either a unified diff file, or a git range. With
+--repo-config, the configuration is checked against the server's policy for
+the repository, as the server would.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir := "."
+			if len(args) > 0 {
+				dir = args[0]
+			}
+			if (diffFile == "") == (gitRange == "") {
+				return errors.New("one of --diff or --range must be set")
+			}
+
+			policy, err := loadPolicy(repoConfig, repoName)
+			if err != nil {
+				return err
+			}
+			src := discovery.DirSource(dir)
+			cfg, err := loadConfig(src, policy)
+			if err != nil {
+				return err
+			}
+			diff, err := readDiff(dir, diffFile, gitRange)
+			if err != nil {
+				return err
+			}
+			changes, _, err := gitdiff.Parse(bytes.NewReader(diff))
+			if err != nil {
+				return fmt.Errorf("error parsing diff: %w", err)
+			}
+			paths := trigger.ChangedPaths(changes)
+
+			out := cmd.OutOrStdout()
+			for _, autoPlot := range []bool{false, true} {
+				triggers, err := trigger.Projects(cfg, paths, autoPlot, src)
+				if err != nil {
+					return err
+				}
+				if autoPlot {
+					fmt.Fprintln(out, "\nauto-plot:")
+				} else {
+					fmt.Fprintln(out, "plot:")
+				}
+				for _, t := range triggers {
+					fmt.Fprintf(out, "  %s: %s\n", t.Project.DisplayName(), describeTrigger(t))
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&diffFile, "diff", "", "the unified diff file, or - to read it from stdin")
+	cmd.Flags().StringVar(&gitRange, "range", "", "the git range to diff, e.g. main...HEAD")
+	cmd.Flags().StringVar(&repoConfig, "repo-config", "", "the server's repositories configuration file")
+	cmd.Flags().StringVar(&repoName, "repo-name", "", "the full name of the repository in the server's repositories configuration, e.g. owner/name")
+	return cmd
+}
+
+// loadPolicy returns the server's policy for the repository, from its
+// repositories configuration file. Without a file, there's no policy.
+func loadPolicy(file, repoName string) (yaml.Policy, error) {
+	if file == "" {
+		return yaml.Policy{}, nil
+	}
+	if repoName == "" {
+		return yaml.Policy{}, errors.New("--repo-name must be set with --repo-config")
+	}
+	repoConfig, err := config.LoadRepoConfig(file)
+	if err != nil {
+		return yaml.Policy{}, fmt.Errorf("error loading %s: %w", file, err)
+	}
+	repo, ok := repoConfig.Find(repoName)
+	if !ok {
+		return yaml.Policy{}, fmt.Errorf("repository %s isn't allowed by %s", repoName, file)
+	}
+	return repoConfig.Policy(repo), nil
+}
+
+// loadConfig loads and validates the configuration of the repository, with
+// its included and discovered projects, checked against the policy.
+func loadConfig(src discovery.Source, policy yaml.Policy) (yaml.Config, error) {
+	cfg, err := discovery.LoadConfig(src, policy)
+	if err != nil {
+		var cfgErr *yaml.ConfigError
+		if errors.As(err, &cfgErr) {
+			return cfg, errors.New(cfgErr.Details())
+		}
+		return cfg, err
+	}
+	return cfg, plugin.Validate(cfg)
+}
+
+// readDiff returns the diff from the file, or of the git range in dir.
+func readDiff(dir, file, gitRange string) ([]byte, error) {
+	switch {
+	case file == "-":
+		return io.ReadAll(os.Stdin)
+	case file != "":
+		return os.ReadFile(file)
+	default:
+		c := exec.Command("git", "-C", dir, "diff", "--no-color", "--no-ext-diff", gitRange)
+		var stderr bytes.Buffer
+		c.Stderr = &stderr
+		out, err := c.Output()
+		if err != nil {
+			return nil, fmt.Errorf("git diff %s: %s", gitRange, strings.TrimSpace(stderr.String()))
+		}
+		return out, nil
+	}
+}
+
+func describeTrigger(t trigger.Trigger) string {
+	switch {
+	case t.AutoPlotDisabled && t.Path != "":
+		return fmt.Sprintf("skipped, auto plot is not set (%s matches %s)", t.Path, t.Rule)
+	case t.AutoPlotDisabled:
+		return "skipped, auto plot is not set"
+	case t.Triggered():
+		return fmt.Sprintf("triggered, %s matches %s", t.Path, t.Rule)
+	default:
+		return fmt.Sprintf("not triggered, no changed path matches %s", strings.Join(t.Rules, ", "))
+	}
+}
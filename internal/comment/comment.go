@@ -0,0 +1,128 @@
+package comment
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/charmbracelet/log"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+
+	pb "github.com/ivanvc/turnip/pkg/turnip"
+)
+
+// MaxLength is the maximum size of a GitHub comment body.
+const MaxLength = 65536
+
+// partHeaderReserve is the room left in each part for its header.
+const partHeaderReserve = 512
+
+// Options configures how job results are rendered.
+type Options struct {
+	// MaxOutputSize is the output size after which the output is truncated
+	// instead of split across several comments.
+	MaxOutputSize int
+	// LogURL is a template for the link to the job's full log. It's executed
+	// with the JobFinishedRequest.
+	LogURL string
+	// Format renders the output of the given adapter as Markdown. It returns
+	// false if the output can't be formatted, in which case it's rendered as
+	// a diff.
+	Format func(adapter string, output []byte) (string, bool)
+}
+
+// Render returns the body of the comment reporting a finished job.
+func Render(in *pb.JobFinishedRequest, opts Options) string {
+	return renderHeader(in) + renderDetails(in, opts)
+}
+
+// RenderParts returns the bodies of the comments reporting a finished job. If
+// the report doesn't fit in a single comment, it's split in numbered parts. If
+// the output is bigger than MaxOutputSize, a truncated report with a link to
+// the full log is returned instead.
+func RenderParts(in *pb.JobFinishedRequest, opts Options) []string {
+	body := Render(in, opts)
+	if len(body) <= MaxLength {
+		return []string{body}
+	}
+	if opts.MaxOutputSize > 0 && len(in.GetOutput()) > opts.MaxOutputSize {
+		return []string{RenderTruncated(in, opts, MaxLength)}
+	}
+
+	chunks := Split(renderDetails(in, opts), MaxLength-partHeaderReserve)
+	parts := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		parts[i] = fmt.Sprintf("%s (part %d/%d)%s", renderHeader(in), i+1, len(chunks), chunk)
+	}
+	return parts
+}
+
+// RenderTruncated returns the report of a finished job, truncating the
+// output so it fits in maxLength bytes, and linking to the full log.
+func RenderTruncated(in *pb.JobFinishedRequest, opts Options, maxLength int) string {
+	body := Render(in, opts)
+	if len(body) <= maxLength {
+		return body
+	}
+	return Truncate(body, truncatedNote(in, opts), maxLength)
+}
+
+func renderHeader(in *pb.JobFinishedRequest) string {
+	project := fmt.Sprintf("%s %s", in.GetProjectDir(), in.GetProjectWorkspace())
+	if in.GetProjectName() != "" {
+		project = fmt.Sprintf("%s (%s)", in.GetProjectName(), strings.TrimSpace(project))
+	}
+	return fmt.Sprintf(
+		"Ran %s for %s\n\nStatus: %s",
+		in.GetCommand(),
+		project,
+		cases.Title(language.English).String(in.GetStatus().String()),
+	)
+}
+
+// renderDetails returns the collapsible section with the output and error of
+// the job.
+func renderDetails(in *pb.JobFinishedRequest, opts Options) string {
+	var sb strings.Builder
+	sb.WriteString("\n\n<details><summary>Show Output</summary>\n\n")
+	if len(in.GetOutput()) > 0 {
+		if out, ok := formatOutput(in, opts); ok {
+			sb.WriteString(out + "\n\n")
+		} else {
+			sb.WriteString(fmt.Sprintf("```diff\n%s\n```\n", in.GetOutput()))
+		}
+	}
+	if in.GetError() != "" {
+		sb.WriteString(fmt.Sprintf("Error:\n```\n%s\n```\n", in.GetError()))
+	}
+	sb.WriteString("</details>")
+	return sb.String()
+}
+
+func formatOutput(in *pb.JobFinishedRequest, opts Options) (string, bool) {
+	if opts.Format == nil {
+		return "", false
+	}
+	return opts.Format(in.GetAdapter(), in.GetOutput())
+}
+
+func truncatedNote(in *pb.JobFinishedRequest, opts Options) string {
+	note := fmt.Sprintf("_Output truncated, it was %d bytes long._", len(in.GetOutput()))
+	if opts.LogURL == "" {
+		return note + " _See the runner logs for the full output._"
+	}
+
+	tpl, err := template.New("logURL").Parse(opts.LogURL)
+	if err != nil {
+		log.Error("error parsing log URL template", "error", err)
+		return note
+	}
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, in); err != nil {
+		log.Error("error executing log URL template", "error", err)
+		return note
+	}
+	return fmt.Sprintf("%s [See the full log](%s).", note, buf.String())
+}
@@ -0,0 +1,167 @@
+package comment
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	fence        = "```"
+	detailsOpen  = "<details>"
+	detailsClose = "</details>"
+)
+
+// Split splits body at line boundaries into chunks of at most maxLength
+// bytes. Code fences and <details> blocks open at a split are closed at the
+// end of the chunk and reopened at the beginning of the next one, so every
+// chunk renders on its own.
+func Split(body string, maxLength int) []string {
+	if len(body) <= maxLength {
+		return []string{body}
+	}
+
+	chunks := make([]string, 0)
+	var cur strings.Builder
+	var open []string
+	hasContent := false
+
+	flush := func() {
+		chunk := cur.String()
+		if closers := closeBlocks(open); closers != "" {
+			if !strings.HasSuffix(chunk, "\n") {
+				chunk += "\n"
+			}
+			chunk += closers
+		}
+		chunks = append(chunks, chunk)
+		cur.Reset()
+		cur.WriteString(reopenBlocks(open))
+		hasContent = false
+	}
+
+	for _, line := range splitLongLines(body, maxLength/2) {
+		// Reserve room to close the blocks that will be open after this line.
+		next := updateBlocks(open, line)
+		reserve := len(closeBlocks(next)) + 1
+		if hasContent && cur.Len()+len(line)+reserve > maxLength {
+			flush()
+		}
+		cur.WriteString(line)
+		hasContent = true
+		open = next
+	}
+	if hasContent {
+		chunks = append(chunks, cur.String())
+	}
+
+	return chunks
+}
+
+// Truncate returns body cut down at a line boundary to at most maxLength
+// bytes, keeping its fences and <details> blocks balanced.
+func Truncate(body, note string, maxLength int) string {
+	if len(body) <= maxLength {
+		return body
+	}
+	chunk := Split(body, maxLength-len(note)-2)[0]
+	return chunk + "\n" + note + "\n"
+}
+
+// updateBlocks returns the stack of open blocks after the given line.
+func updateBlocks(open []string, line string) []string {
+	trimmed := strings.TrimSpace(line)
+	if n := len(open); n > 0 && strings.HasPrefix(open[n-1], fence) {
+		if trimmed == fence {
+			return open[:n-1]
+		}
+		return open
+	}
+	if strings.HasPrefix(trimmed, fence) {
+		return append(clone(open), trimmed)
+	}
+
+	next := open
+	for _, tok := range detailsTokens(trimmed) {
+		if tok == detailsClose {
+			if len(next) > 0 {
+				next = next[:len(next)-1]
+			}
+			continue
+		}
+		next = append(clone(next), strings.TrimSpace(line))
+	}
+	return next
+}
+
+// detailsTokens returns the <details> and </details> tags in line, in order.
+func detailsTokens(line string) []string {
+	tokens := make([]string, 0)
+	for {
+		o, c := strings.Index(line, detailsOpen), strings.Index(line, detailsClose)
+		switch {
+		case o < 0 && c < 0:
+			return tokens
+		case c < 0 || (o >= 0 && o < c):
+			tokens = append(tokens, detailsOpen)
+			line = line[o+len(detailsOpen):]
+		default:
+			tokens = append(tokens, detailsClose)
+			line = line[c+len(detailsClose):]
+		}
+	}
+}
+
+func closeBlocks(open []string) string {
+	var sb strings.Builder
+	for i := len(open) - 1; i >= 0; i-- {
+		if strings.HasPrefix(open[i], fence) {
+			sb.WriteString(fence + "\n")
+		} else {
+			sb.WriteString(detailsClose + "\n")
+		}
+	}
+	return sb.String()
+}
+
+func reopenBlocks(open []string) string {
+	var sb strings.Builder
+	for _, o := range open {
+		sb.WriteString(o + "\n")
+		if !strings.HasPrefix(o, fence) {
+			// Markdown needs a blank line after the HTML block.
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+// splitLongLines splits body into lines, breaking the ones longer than
+// maxLength at a valid UTF-8 boundary. Every piece holds at least one rune,
+// even if it's longer than maxLength.
+func splitLongLines(body string, maxLength int) []string {
+	if maxLength < 1 {
+		maxLength = 1
+	}
+	lines := make([]string, 0)
+	for _, line := range strings.SplitAfter(body, "\n") {
+		for len(line) > maxLength {
+			i := maxLength
+			for i > 0 && !utf8.RuneStart(line[i]) {
+				i--
+			}
+			if i <= 0 {
+				_, i = utf8.DecodeRuneInString(line)
+			}
+			lines = append(lines, line[:i]+"\n")
+			line = line[i:]
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func clone(s []string) []string {
+	return append(make([]string, 0, len(s)+1), s...)
+}
@@ -0,0 +1,84 @@
+package comment
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("<details><summary>Show Output</summary>\n\n```diff\n")
+	for i := 0; i < 100; i++ {
+		sb.WriteString(fmt.Sprintf("+ resource %03d\n", i))
+	}
+	sb.WriteString("```\n</details>")
+	body := sb.String()
+
+	chunks := Split(body, 300)
+	if len(chunks) < 2 {
+		t.Fatalf("expected body to be split, got %d chunks", len(chunks))
+	}
+	for i, chunk := range chunks {
+		if len(chunk) > 300 {
+			t.Errorf("chunk %d is %d bytes long", i, len(chunk))
+		}
+		if n := strings.Count(chunk, "```"); n%2 != 0 {
+			t.Errorf("chunk %d has unbalanced fences: %q", i, chunk)
+		}
+		if strings.Count(chunk, "<details>") != strings.Count(chunk, "</details>") {
+			t.Errorf("chunk %d has unbalanced details: %q", i, chunk)
+		}
+		if !strings.HasPrefix(chunk, "<details><summary>Show Output</summary>\n\n") {
+			t.Errorf("chunk %d doesn't reopen details: %q", i, chunk)
+		}
+	}
+	for i := 0; i < 100; i++ {
+		line := fmt.Sprintf("+ resource %03d\n", i)
+		if !strings.Contains(strings.Join(chunks, ""), line) {
+			t.Errorf("expected %q to be in a chunk", line)
+		}
+	}
+}
+
+func TestSplitShortBody(t *testing.T) {
+	chunks := Split("```diff\n+ a\n```", 100)
+	if len(chunks) != 1 || chunks[0] != "```diff\n+ a\n```" {
+		t.Errorf("expected body to be unchanged, got %q", chunks)
+	}
+}
+
+func TestSplitLongLine(t *testing.T) {
+	chunks := Split(strings.Repeat("ñ", 200), 100)
+	for i, chunk := range chunks {
+		if len(chunk) > 100 {
+			t.Errorf("chunk %d is %d bytes long", i, len(chunk))
+		}
+		if !strings.HasPrefix(strings.TrimSpace(chunk), "ñ") {
+			t.Errorf("chunk %d was split in the middle of a rune: %q", i, chunk)
+		}
+	}
+}
+
+func TestSplitSmallMaxLength(t *testing.T) {
+	for _, maxLength := range []int{-1, 0, 1, 2, 3} {
+		chunks := Split("ab\n\nñc\n", maxLength)
+		if joined := strings.ReplaceAll(strings.Join(chunks, ""), "\n", ""); joined != "abñc" {
+			t.Errorf("expected every rune to be kept with maxLength %d, got %q", maxLength, chunks)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	body := "```diff\n" + strings.Repeat("+ line\n", 100) + "```"
+	out := Truncate(body, "_truncated_", 100)
+	if len(out) > 100 {
+		t.Errorf("expected at most 100 bytes, got %d", len(out))
+	}
+	if strings.Count(out, "```")%2 != 0 {
+		t.Errorf("expected balanced fences, got %q", out)
+	}
+	if !strings.HasSuffix(out, "_truncated_\n") {
+		t.Errorf("expected note at the end, got %q", out)
+	}
+}
@@ -0,0 +1,150 @@
+package comment
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	summaryMarkerPrefix = "<!-- turnip:summary "
+	sectionStartPrefix  = "<!-- turnip:section "
+	sectionEnd          = "<!-- /turnip:section -->"
+	markerSuffix        = " -->"
+)
+
+// Summary is the single comment that turnip keeps updated in a pull request,
+// with one section per project.
+type Summary struct {
+	SHA      string
+	Outdated bool
+	sections []section
+}
+
+type section struct {
+	key  string
+	body string
+}
+
+// NewSummary returns an empty summary for the given head commit.
+func NewSummary(sha string) *Summary {
+	return &Summary{SHA: sha}
+}
+
+// ParseSummary parses the body of a comment previously generated by
+// Summary.String. It returns false if the body is not a turnip summary.
+func ParseSummary(body string) (*Summary, bool) {
+	header, rest, _ := strings.Cut(body, "\n")
+	if !strings.HasPrefix(header, summaryMarkerPrefix) || !strings.HasSuffix(header, markerSuffix) {
+		return nil, false
+	}
+
+	s := new(Summary)
+	attrs := strings.TrimSuffix(strings.TrimPrefix(header, summaryMarkerPrefix), markerSuffix)
+	for _, attr := range strings.Fields(attrs) {
+		switch {
+		case strings.HasPrefix(attr, "sha="):
+			s.SHA = strings.TrimPrefix(attr, "sha=")
+		case attr == "outdated":
+			s.Outdated = true
+		}
+	}
+
+	for {
+		start := strings.Index(rest, sectionStartPrefix)
+		if start < 0 {
+			break
+		}
+		rest = rest[start+len(sectionStartPrefix):]
+		marker, after, ok := strings.Cut(rest, markerSuffix+"\n")
+		if !ok {
+			break
+		}
+		key, err := strconv.Unquote(strings.TrimPrefix(marker, "key="))
+		if err != nil {
+			break
+		}
+		end := strings.Index(after, "\n"+sectionEnd)
+		if end < 0 {
+			break
+		}
+		s.sections = append(s.sections, section{key, after[:end]})
+		rest = after[end+len(sectionEnd)+1:]
+	}
+
+	return s, true
+}
+
+// SectionKey returns the key that identifies a project's section, its name
+// if it has one.
+func SectionKey(name, dir, workspace string) string {
+	if name != "" {
+		return name
+	}
+	if workspace == "" {
+		return dir
+	}
+	return dir + ":" + workspace
+}
+
+// SetSection replaces the body of the section with the given key, or appends
+// a new section if it does not exist yet.
+func (s *Summary) SetSection(key, body string) {
+	for i := range s.sections {
+		if s.sections[i].key == key {
+			s.sections[i].body = body
+			return
+		}
+	}
+	s.sections = append(s.sections, section{key, body})
+}
+
+// Fit truncates the biggest sections until the summary is at most maxLength
+// bytes long.
+func (s *Summary) Fit(maxLength int) {
+	const note = "_Output truncated to fit in the summary._"
+	for len(s.String()) > maxLength {
+		biggest := -1
+		for i := range s.sections {
+			if len(s.sections[i].body) > len(note)*4 && (biggest < 0 || len(s.sections[i].body) > len(s.sections[biggest].body)) {
+				biggest = i
+			}
+		}
+		if biggest < 0 {
+			return
+		}
+		body := s.sections[biggest].body
+		s.sections[biggest].body = Truncate(body, note, len(body)/2)
+	}
+}
+
+// String returns the summary as a comment body.
+func (s *Summary) String() string {
+	var sb strings.Builder
+	sb.WriteString(summaryMarkerPrefix + "sha=" + s.SHA)
+	if s.Outdated {
+		sb.WriteString(" outdated")
+	}
+	sb.WriteString(markerSuffix + "\n")
+
+	sb.WriteString(fmt.Sprintf("### Turnip summary for %s", shortSHA(s.SHA)))
+	if s.Outdated {
+		sb.WriteString(" (outdated)")
+	}
+	sb.WriteString("\n")
+
+	for _, sec := range s.sections {
+		sb.WriteString(fmt.Sprintf("\n%skey=%s%s\n", sectionStartPrefix, strconv.Quote(sec.key), markerSuffix))
+		sb.WriteString(sec.body)
+		sb.WriteString("\n" + sectionEnd + "\n")
+	}
+
+	return sb.String()
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
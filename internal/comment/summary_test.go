@@ -0,0 +1,58 @@
+package comment
+
+import "testing"
+
+func TestSummaryRoundTrip(t *testing.T) {
+	s := NewSummary("0123456789abcdef")
+	s.SetSection("infra:dev", "Ran preview for infra dev")
+	s.SetSection("apps", "Ran diff for apps\n\n```diff\n+ added\n```")
+
+	parsed, ok := ParseSummary(s.String())
+	if !ok {
+		t.Fatalf("expected %q to be parsed as a summary", s.String())
+	}
+	if parsed.SHA != s.SHA {
+		t.Errorf("expected SHA %q, got %q", s.SHA, parsed.SHA)
+	}
+	if parsed.String() != s.String() {
+		t.Errorf("expected %q, got %q", s.String(), parsed.String())
+	}
+}
+
+func TestSummarySetSection(t *testing.T) {
+	s := NewSummary("abc")
+	s.SetSection("infra:dev", "first run")
+	s.SetSection("infra:prod", "prod run")
+	s.SetSection("infra:dev", "second run")
+
+	parsed, _ := ParseSummary(s.String())
+	if len(parsed.sections) != 2 {
+		t.Fatalf("expected 2 sections, got %d", len(parsed.sections))
+	}
+	if parsed.sections[0].key != "infra:dev" || parsed.sections[0].body != "second run" {
+		t.Errorf("expected the first section to be replaced, got %+v", parsed.sections[0])
+	}
+}
+
+func TestParseSummary(t *testing.T) {
+	tt := []struct {
+		body     string
+		ok       bool
+		outdated bool
+	}{
+		{"Ran preview for infra dev", false, false},
+		{"<!-- turnip:summary sha=abc -->\n### Turnip summary for abc\n", true, false},
+		{"<!-- turnip:summary sha=abc outdated -->\n### Turnip summary for abc (outdated)\n", true, true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.body, func(t *testing.T) {
+			s, ok := ParseSummary(tc.body)
+			if ok != tc.ok {
+				t.Fatalf("expected ok to be %v, got %v", tc.ok, ok)
+			}
+			if ok && s.Outdated != tc.outdated {
+				t.Errorf("expected outdated to be %v, got %v", tc.outdated, s.Outdated)
+			}
+		})
+	}
+}
@@ -2,12 +2,28 @@ package common
 
 import (
 	"github.com/ivanvc/turnip/internal/adapters/github"
+	"github.com/ivanvc/turnip/internal/adapters/gitlab"
 	"github.com/ivanvc/turnip/internal/config"
-	"github.com/ivanvc/turnip/internal/services/kubernetes"
+	"github.com/ivanvc/turnip/internal/scheduler"
+	"github.com/ivanvc/turnip/internal/services/executor"
+	"github.com/ivanvc/turnip/internal/vcs"
 )
 
 type Common struct {
 	*config.Config
-	KubernetesClient *kubernetes.Client
-	GitHubClient     *github.Client
+	Executor     executor.Executor
+	GitHubClient *github.Client
+	// GitLabClient is nil if GitLab is not enabled.
+	GitLabClient *gitlab.Client
+	Scheduler    *scheduler.Scheduler
+}
+
+// VCSClient returns the client reporting the jobs, to GitLab if their URLs
+// are in its instance, or to GitHub.
+func (c *Common) VCSClient() vcs.Client {
+	mux := vcs.NewMux(c.GitHubClient)
+	if c.GitLabClient != nil {
+		mux.Handle(c.GitLabClient.URL()+"/", c.GitLabClient)
+	}
+	return mux
 }
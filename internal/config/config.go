@@ -5,6 +5,7 @@ import (
 	"flag"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/charmbracelet/log"
 )
@@ -14,12 +15,27 @@ type Config struct {
 	ListenRPC                  string
 	LogLevel                   string
 	GitHubToken                string
+	GitLabURL                  string
+	GitLabToken                string
+	GitLabWebhookSecret        string
 	Namespace                  string
 	ServerName                 string
 	JobSecretsName             string
 	JobTTLSecondsAfterFinished int
 	RunnerPodAnnotations       map[string]string
 	APIToken                   string
+	SummaryComment             bool
+	MaxOutputSize              int
+	OutputLogURL               string
+	RedactPatterns             []string
+	RepoConfig                 *RepoConfig
+	DependencyTimeout          time.Duration
+	// Executor runs the jobs, one of kubernetes, local or docker.
+	Executor      string
+	RunnerPath    string
+	RunnerImage   string
+	DockerNetwork string
+	JobEnvFile    string
 }
 
 func Load() *Config {
@@ -28,6 +44,9 @@ func Load() *Config {
 	flag.StringVar(&c.ListenHTTP, "listen-http", envOrDefault("TURNIP_LISTEN_HTTP", ":8080"), "The address the HTTP server binds to.")
 	flag.StringVar(&c.LogLevel, "log-level", envOrDefault("TURNIP_LOG_LEVEL", "info"), "The log level.")
 	flag.StringVar(&c.GitHubToken, "github-token", envOrDefault("TURNIP_GITHUB_TOKEN", ""), "GitHub token.")
+	flag.StringVar(&c.GitLabURL, "gitlab-url", envOrDefault("TURNIP_GITLAB_URL", ""), "URL of the GitLab instance, e.g. https://gitlab.example.com. Leave empty to disable GitLab.")
+	flag.StringVar(&c.GitLabToken, "gitlab-token", envOrDefault("TURNIP_GITLAB_TOKEN", ""), "GitLab token, with the api scope.")
+	flag.StringVar(&c.GitLabWebhookSecret, "gitlab-webhook-secret", envOrDefault("TURNIP_GITLAB_WEBHOOK_SECRET", ""), "Secret token of the GitLab webhooks.")
 	flag.StringVar(&c.Namespace, "namespace", envOrDefault("TURNIP_NAMESPACE", ""), "Namespace where turnip has access to create jobs.")
 	flag.StringVar(&c.ServerName, "server-name", envOrDefault("TURNIP_SERVER_NAME", "turnip"), "Server name to use to communicate using RPC.")
 	flag.StringVar(&c.JobSecretsName, "job-secrets-name", envOrDefault("TURNIP_RUNNER_JOB_SECRETS_NAME", "turnip-runner-job-secrets"), "Name of the secret to use for job secrets.")
@@ -39,7 +58,30 @@ func Load() *Config {
 	}
 	flag.IntVar(&c.JobTTLSecondsAfterFinished, "job-ttl-seconds-after-finished", i, "TTL for jobs after they finish.")
 	flag.StringVar(&c.APIToken, "api-token", envOrDefault("TURNIP_API_TOKEN", ""), "API token to use for API calls.")
+	flag.BoolVar(&c.SummaryComment, "summary-comment", boolEnvOrDefault("TURNIP_SUMMARY_COMMENT", false), "Keep a single summary comment per pull request instead of one comment per job.")
+	maxOutputSize := envOrDefault("TURNIP_MAX_OUTPUT_SIZE", "262144")
+	mos, err := strconv.Atoi(maxOutputSize)
+	if err != nil {
+		log.Error("error parsing max-output-size, using 262144 as default", "error", err)
+		mos = 262144
+	}
+	flag.IntVar(&c.MaxOutputSize, "max-output-size", mos, "Output size in bytes after which it's truncated instead of split across several comments.")
+	dependencyTimeout := envOrDefault("TURNIP_DEPENDENCY_TIMEOUT", "2h")
+	dt, err := time.ParseDuration(dependencyTimeout)
+	if err != nil {
+		log.Error("error parsing dependency-timeout, using 2h as default", "error", err)
+		dt = 2 * time.Hour
+	}
+	flag.DurationVar(&c.DependencyTimeout, "dependency-timeout", dt, "Time after which a job still waiting for its dependencies is skipped, 0 to wait indefinitely.")
+	flag.StringVar(&c.OutputLogURL, "output-log-url", envOrDefault("TURNIP_OUTPUT_LOG_URL", ""), "Template for the link to a job's full log, used when its output is truncated.")
 	annotations := flag.String("runner-pod-annotations", envOrDefault("TURNIP_RUNNER_POD_ANNOTATIONS", "{}"), "Annotations to add to the runner pod.")
+	redactPatterns := flag.String("redact-patterns", envOrDefault("TURNIP_REDACT_PATTERNS", "[]"), "JSON list of regular expressions whose matches are redacted from the job output.")
+	flag.StringVar(&c.Executor, "executor", envOrDefault("TURNIP_EXECUTOR", "kubernetes"), "Where the jobs run, one of kubernetes, local (a subprocess of the server) or docker.")
+	flag.StringVar(&c.RunnerPath, "runner-path", envOrDefault("TURNIP_RUNNER_PATH", "xl-15"), "Path to the runner binary, for the local executor.")
+	flag.StringVar(&c.RunnerImage, "runner-image", envOrDefault("TURNIP_RUNNER_IMAGE", "ivan/turnip:latest"), "Image of the runner, for the docker executor.")
+	flag.StringVar(&c.DockerNetwork, "docker-network", envOrDefault("TURNIP_DOCKER_NETWORK", ""), "Network of the runner containers, for the docker executor.")
+	flag.StringVar(&c.JobEnvFile, "job-env-file", envOrDefault("TURNIP_JOB_ENV_FILE", ""), "File with the KEY=value job secrets, for the local and docker executors.")
+	repoConfig := flag.String("repo-config", envOrDefault("TURNIP_REPO_CONFIG", ""), "Path to the file with the allowed repositories, and the server owned workflows.")
 	flag.Parse()
 
 	if err := json.Unmarshal([]byte(*annotations), &c.RunnerPodAnnotations); err != nil {
@@ -47,6 +89,29 @@ func Load() *Config {
 		c.RunnerPodAnnotations = make(map[string]string)
 	}
 
+	if err := json.Unmarshal([]byte(*redactPatterns), &c.RedactPatterns); err != nil {
+		log.Error("error parsing redact-patterns", "error", err)
+		c.RedactPatterns = make([]string, 0)
+	}
+
+	if *repoConfig != "" {
+		rc, err := LoadRepoConfig(*repoConfig)
+		if err != nil {
+			log.Fatal("error loading repo-config", "error", err)
+		}
+		c.RepoConfig = rc
+	}
+
+	switch c.Executor {
+	case "kubernetes", "local", "docker":
+	default:
+		log.Fatal("unknown executor, must be one of kubernetes, local or docker", "executor", c.Executor)
+	}
+
+	if c.GitLabURL != "" && c.GitLabWebhookSecret == "" {
+		log.Fatal("gitlab-webhook-secret is required to enable GitLab")
+	}
+
 	return c
 }
 
@@ -56,3 +121,16 @@ func envOrDefault(variable, fallback string) string {
 	}
 	return fallback
 }
+
+func boolEnvOrDefault(variable string, fallback bool) bool {
+	v, ok := os.LookupEnv(variable)
+	if !ok {
+		return fallback
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Error("error parsing boolean environment variable, using default", "variable", variable, "error", err)
+		return fallback
+	}
+	return b
+}
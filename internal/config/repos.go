@@ -0,0 +1,110 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/bmatcuk/doublestar"
+	"gopkg.in/yaml.v3"
+
+	turnipyaml "github.com/ivanvc/turnip/internal/yaml"
+)
+
+const (
+	// ConfigFromHead loads the repository's configuration from the pull
+	// request's head, so its changes take effect in the same pull request.
+	ConfigFromHead = "head"
+	// ConfigFromBase loads the repository's configuration from the pull
+	// request's base branch, so its changes only take effect once merged.
+	ConfigFromBase = "base"
+	// ConfigFromApproved loads the repository's configuration from the pull
+	// request's base branch, unless the pull request changes it and its head
+	// commit is approved, then from its head.
+	ConfigFromApproved = "approved"
+)
+
+// RepoConfig is the server side configuration of the repositories.
+type RepoConfig struct {
+	// Repos are the repositories turnip runs on. Every repository is allowed,
+	// with the default settings, if it's empty.
+	Repos []Repo `yaml:"repos"`
+	// Workflows are owned by the server, the repositories can use them but
+	// not override them.
+	Workflows map[string]turnipyaml.Workflow `yaml:"workflows"`
+}
+
+// Repo holds the settings of the repositories matching its ID.
+type Repo struct {
+	// ID is the full name of the repository, or a glob matching it, e.g.
+	// ivanvc/*. As in paths, * doesn't match /, so GitLab subgroups are
+	// matched with **, e.g. group/** matches group/sub/project.
+	ID string `yaml:"id"`
+	// AllowedKeys are the turnip.yaml keys the repository can set, see
+	// yaml.Policy. Every key is allowed if it's empty.
+	AllowedKeys []string `yaml:"allowedKeys"`
+	// ConfigFrom is where the configuration is loaded from for pull requests,
+	// either head (the default), base or approved.
+	ConfigFrom string `yaml:"configFrom"`
+}
+
+// LoadRepoConfig loads the repositories configuration from the file.
+func LoadRepoConfig(file string) (*RepoConfig, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var c RepoConfig
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	return &c, c.Validate()
+}
+
+func (c *RepoConfig) Validate() error {
+	for _, r := range c.Repos {
+		if _, err := doublestar.Match(r.ID, ""); err != nil {
+			return fmt.Errorf("repo %s: invalid id: %s", r.ID, err.Error())
+		}
+		switch r.ConfigFrom {
+		case "", ConfigFromHead, ConfigFromBase, ConfigFromApproved:
+		default:
+			return fmt.Errorf("repo %s: configFrom must be one of %s, %s or %s", r.ID, ConfigFromHead, ConfigFromBase, ConfigFromApproved)
+		}
+	}
+	for name, w := range c.Workflows {
+		if err := w.Validate(); err != nil {
+			return fmt.Errorf("workflow %s: %s", name, err.Error())
+		}
+	}
+	return nil
+}
+
+// Find returns the settings of the first repo matching the repository's full
+// name, or false if it's not allowed.
+func (c *RepoConfig) Find(fullName string) (Repo, bool) {
+	if c == nil || len(c.Repos) == 0 {
+		return Repo{ID: fullName}, true
+	}
+	for _, r := range c.Repos {
+		if ok, _ := doublestar.Match(r.ID, fullName); ok {
+			return r, true
+		}
+	}
+	return Repo{}, false
+}
+
+// Policy returns the policy the repository's configuration is checked
+// against.
+func (c *RepoConfig) Policy(r Repo) turnipyaml.Policy {
+	p := turnipyaml.Policy{AllowedKeys: r.AllowedKeys}
+	if c != nil {
+		p.Workflows = c.Workflows
+	}
+	return p
+}
+
+// FromBase returns whether the repository's configuration is loaded from the
+// pull request's base branch, at least until its changes are approved.
+func (r Repo) FromBase() bool {
+	return r.ConfigFrom == ConfigFromBase || r.ConfigFrom == ConfigFromApproved
+}
@@ -0,0 +1,25 @@
+package config
+
+import "testing"
+
+func TestFind(t *testing.T) {
+	c := &RepoConfig{Repos: []Repo{{ID: "ivanvc/*"}, {ID: "infra/**"}}}
+
+	tt := []struct {
+		fullName string
+		expected bool
+	}{
+		{"ivanvc/turnip", true},
+		{"ivanvc/sub/turnip", false},
+		{"infra/live", true},
+		{"infra/team/live", true},
+		{"other/live", false},
+	}
+	for _, tc := range tt {
+		t.Run(tc.fullName, func(t *testing.T) {
+			if _, ok := c.Find(tc.fullName); ok != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, ok)
+			}
+		})
+	}
+}
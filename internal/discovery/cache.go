@@ -0,0 +1,102 @@
+package discovery
+
+import "sync"
+
+// Cache keeps the files fetched from the sources of a commit. They never
+// change, so they're fetched only once across the events of a pull request.
+type Cache struct {
+	mu       sync.Mutex
+	maxFiles int
+	files    map[string][]byte
+	lists    map[string][]string
+}
+
+// NewCache returns a cache that's emptied once it holds maxFiles files, or
+// lists of files.
+func NewCache(maxFiles int) *Cache {
+	return &Cache{
+		maxFiles: maxFiles,
+		files:    make(map[string][]byte),
+		lists:    make(map[string][]string),
+	}
+}
+
+// Source returns the source with its files cached under the repository and
+// commit. Sources without a commit aren't cached.
+func (c *Cache) Source(src Source, repo, sha string) Source {
+	if sha == "" {
+		return src
+	}
+	return &commitSource{Source: src, cache: c, key: repo + "@" + sha}
+}
+
+func (c *Cache) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, ok := c.files[key]
+	return data, ok
+}
+
+func (c *Cache) set(key string, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.evict()
+	c.files[key] = data
+}
+
+func (c *Cache) getList(key string) ([]string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	files, ok := c.lists[key]
+	return files, ok
+}
+
+func (c *Cache) setList(key string, files []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.evict()
+	c.lists[key] = files
+}
+
+// evict empties the cache once it's full. It has to be called with the lock
+// held.
+func (c *Cache) evict() {
+	if len(c.files)+len(c.lists) >= c.maxFiles {
+		c.files = make(map[string][]byte)
+		c.lists = make(map[string][]string)
+	}
+}
+
+// commitSource is a Source of a commit, with its files cached.
+type commitSource struct {
+	Source
+	cache *Cache
+	key   string
+}
+
+func (s *commitSource) ListFiles() ([]string, error) {
+	if files, ok := s.cache.getList(s.key); ok {
+		return files, nil
+	}
+
+	files, err := s.Source.ListFiles()
+	if err != nil {
+		return nil, err
+	}
+	s.cache.setList(s.key, files)
+	return files, nil
+}
+
+func (s *commitSource) FetchFile(path string) ([]byte, error) {
+	key := s.key + ":" + path
+	if data, ok := s.cache.get(key); ok {
+		return data, nil
+	}
+
+	data, err := s.Source.FetchFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.set(key, data)
+	return data, nil
+}
@@ -0,0 +1,50 @@
+package discovery
+
+import "testing"
+
+// countingSource counts the requests to a fakeSource.
+type countingSource struct {
+	fakeSource
+	lists, fetches int
+}
+
+func (s *countingSource) ListFiles() ([]string, error) {
+	s.lists++
+	return s.fakeSource.ListFiles()
+}
+
+func (s *countingSource) FetchFile(path string) ([]byte, error) {
+	s.fetches++
+	return s.fakeSource.FetchFile(path)
+}
+
+func TestCache(t *testing.T) {
+	src := &countingSource{fakeSource: fakeSource{"main.tf": "terraform {}"}}
+	cache := NewCache(10)
+
+	for i := 0; i < 2; i++ {
+		cached := cache.Source(src, "org/repo", "abc123")
+		if _, err := cached.ListFiles(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := cached.FetchFile("main.tf"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := cached.FetchFile("missing.tf"); err == nil {
+			t.Error("expected an error fetching a missing file")
+		}
+	}
+	if src.lists != 1 || src.fetches != 3 {
+		t.Errorf("expected 1 listing and 3 fetches, got %d and %d", src.lists, src.fetches)
+	}
+
+	if _, err := cache.Source(src, "org/repo", "def456").FetchFile("main.tf"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.Source(src, "org/repo", "").FetchFile("main.tf"); err != nil {
+		t.Fatal(err)
+	}
+	if src.fetches != 5 {
+		t.Errorf("expected other commits to be fetched, got %d fetches", src.fetches)
+	}
+}
@@ -0,0 +1,112 @@
+package discovery
+
+import (
+	"github.com/charmbracelet/log"
+
+	"github.com/ivanvc/turnip/internal/yaml"
+)
+
+// ConfigFile is the path of the root configuration file.
+const ConfigFile = "turnip.yaml"
+
+// LoadConfig loads the root configuration file, merges the files it includes,
+// and appends the projects found by its autodiscover rules. The files are
+// checked against the server's policy.
+func LoadConfig(src Source, policy yaml.Policy) (yaml.Config, error) {
+	yml, err := src.FetchFile(ConfigFile)
+	if err != nil {
+		log.Error("error fetching turnip.yaml", "error", err)
+		return yaml.Config{}, err
+	}
+	log.Debug("fetched turnip.yaml", "content", string(yml))
+
+	cfg, err := yaml.LoadWithPolicy(yml, policy)
+	if err != nil {
+		log.Error("error parsing configuration", "error", err)
+		return cfg, err
+	}
+	log.Debug("yaml configuration", "cfg", cfg)
+
+	src = &cachedSource{Source: src}
+	if err := Include(&cfg, src, policy); err != nil {
+		log.Error("error including configuration files", "error", err)
+		return cfg, err
+	}
+	if err := Discover(&cfg, src); err != nil {
+		log.Error("error discovering projects", "error", err)
+		return cfg, err
+	}
+
+	// The dependencies can only be resolved once every project is known.
+	if err := cfg.ValidateDependencies(); err != nil {
+		log.Error("error validating configuration", "error", err)
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// Include merges the files matching the configuration's include globs, and
+// validates the result.
+func Include(cfg *yaml.Config, src Source, policy yaml.Policy) error {
+	if len(cfg.Include) == 0 {
+		return nil
+	}
+
+	files, err := IncludedFiles(cfg.Include, src)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		data, err := src.FetchFile(file)
+		if err != nil {
+			log.Error("error fetching file", "file", file, "error", err)
+			return err
+		}
+		log.Debug("including configuration file", "file", file)
+		if err := cfg.Merge(file, data, policy); err != nil {
+			return err
+		}
+	}
+
+	return cfg.Validate()
+}
+
+// IncludedFiles returns the sorted files matching the include globs, other
+// than the root configuration file.
+func IncludedFiles(include []string, src Source) ([]string, error) {
+	files, err := listFiles(src)
+	if err != nil {
+		return nil, err
+	}
+	included := make([]string, 0)
+	for _, file := range matchingFiles(include, files) {
+		if file != ConfigFile {
+			included = append(included, file)
+		}
+	}
+	return included, nil
+}
+
+// IsConfigFile returns whether the file is the root configuration file, or one
+// included by it.
+func IsConfigFile(cfg yaml.Config, file string) bool {
+	return file == ConfigFile || matchAny(cfg.Include, file)
+}
+
+// cachedSource lists the files only once.
+type cachedSource struct {
+	Source
+	files []string
+}
+
+func (s *cachedSource) ListFiles() ([]string, error) {
+	if s.files != nil {
+		return s.files, nil
+	}
+	files, err := s.Source.ListFiles()
+	if err != nil {
+		return nil, err
+	}
+	s.files = files
+	return files, nil
+}
@@ -0,0 +1,91 @@
+package discovery
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ivanvc/turnip/internal/yaml"
+)
+
+const rootConfig = `version: v1alpha1
+include:
+  - infra/**/turnip.project.yaml
+workflows:
+  tf:
+    terraform:
+      version: 1.9.0
+projects:
+  - dir: apps
+    workflow: tf
+    dependsOn: [infra/cluster]
+`
+
+func TestLoadConfig(t *testing.T) {
+	src := fakeSource{
+		"turnip.yaml":                           rootConfig,
+		"infra/network/turnip.project.yaml":     "projects:\n  - workflow: tf\n    name: network\n",
+		"infra/cluster/turnip.project.yaml":     "projects:\n  - dir: .\n    workflow: tf\n    dependsOn: [../network]\n",
+		"infra/cluster/modules/turnip.yaml.bak": "",
+	}
+
+	cfg, err := LoadConfig(src, yaml.Policy{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := make([]string, 0, len(cfg.Projects))
+	for _, prj := range cfg.Projects {
+		got = append(got, prj.Dir)
+	}
+	if want := []string{"apps", "infra/cluster", "infra/network"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if deps := cfg.Projects[1].DependsOn; !reflect.DeepEqual(deps, []string{"infra/network"}) {
+		t.Errorf("got dependsOn %v, want [infra/network]", deps)
+	}
+	if !cfg.DependsOn(cfg.Projects[0], cfg.Projects[2]) {
+		t.Error("expected apps to depend on network")
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		included string
+		expected string
+	}{
+		{
+			name:     "duplicated project",
+			included: "projects:\n  - dir: ../../apps\n    workflow: tf\n",
+			expected: "project apps: defined more than once",
+		},
+		{
+			name:     "workflows",
+			included: "workflows:\n  tf:\n    terraform:\n      version: 1.8.0\n",
+			expected: "infra/cluster/turnip.project.yaml: workflows can only be defined in the root turnip.yaml",
+		},
+		{
+			name:     "unknown workflow",
+			included: "projects:\n  - workflow: helm\n",
+			expected: "infra/cluster/turnip.project.yaml: project infra/cluster: workflow helm not found",
+		},
+		{
+			name:     "unknown dependency",
+			included: "projects:\n  - workflow: tf\n    dependsOn: [../vpc]\n",
+			expected: "project infra/cluster: dependency infra/vpc not found",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			src := fakeSource{
+				"turnip.yaml":                       rootConfig,
+				"infra/cluster/turnip.project.yaml": tc.included,
+			}
+			_, err := LoadConfig(src, yaml.Policy{})
+			if err == nil || err.Error() != tc.expected {
+				t.Errorf("expected error %q, got %v", tc.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package discovery
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// DirSource is a repository checked out in a local directory.
+type DirSource string
+
+// ListFiles returns the paths of the files under the directory, relative to
+// it, skipping the .git directory.
+func (d DirSource) ListFiles() ([]string, error) {
+	files := make([]string, 0)
+	err := filepath.WalkDir(string(d), func(p string, e fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if e.IsDir() {
+			if e.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(string(d), p)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	return files, err
+}
+
+func (d DirSource) FetchFile(path string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(string(d), filepath.FromSlash(path)))
+}
@@ -0,0 +1,144 @@
+package discovery
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar"
+	"github.com/charmbracelet/log"
+
+	"github.com/ivanvc/turnip/internal/plugin"
+	"github.com/ivanvc/turnip/internal/yaml"
+)
+
+// Source gives access to the files of a repository at a given revision.
+type Source interface {
+	// ListFiles returns the paths of every file in the repository.
+	ListFiles() ([]string, error)
+	// FetchFile returns the contents of the file.
+	FetchFile(path string) ([]byte, error)
+}
+
+// ErrTruncated is returned by the sources that can't list every file of a
+// repository.
+var ErrTruncated = errors.New("the repository has too many files to list them")
+
+// Discover appends the projects found by the configuration's autodiscover
+// rules. The projects declared in the configuration take precedence over the
+// discovered ones in the same directory and workspace.
+func Discover(cfg *yaml.Config, src Source) error {
+	if cfg.Autodiscover == nil {
+		return nil
+	}
+
+	files, err := listFiles(src)
+	if err != nil {
+		return err
+	}
+	dirs := filesByDir(files)
+
+	seen := make(map[string]bool)
+	for _, prj := range cfg.Projects {
+		seen[projectKey(prj.Dir, prj.GetWorkspace())] = true
+	}
+
+	fetcher := plugin.NewFiles(src)
+	for _, rule := range cfg.Autodiscover.Rules {
+		p, _ := plugin.Get(rule.Type)
+		d, ok := p.(plugin.Discoverer)
+		if !ok {
+			return fmt.Errorf("projects of type %s can't be discovered", rule.Type)
+		}
+		for _, dir := range matchingDirs(rule, dirs) {
+			for _, stack := range d.Discover(dir, dirs[dir], fetcher) {
+				prj := rule.Project(dir, stack, cfg.Workflows[rule.Workflow])
+				key := projectKey(prj.Dir, prj.GetWorkspace())
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				log.Debug("discovered project", "dir", prj.Dir, "workspace", prj.GetWorkspace(), "workflow", prj.Workflow)
+				cfg.Projects = append(cfg.Projects, prj)
+			}
+		}
+	}
+
+	return nil
+}
+
+// listFiles lists the files of the source. A truncated listing is reported as
+// a configuration error, as the projects or included files can't be found.
+func listFiles(src Source) ([]string, error) {
+	files, err := src.ListFiles()
+	if errors.Is(err, ErrTruncated) {
+		return nil, &yaml.ConfigError{File: ConfigFile, Msg: err.Error() + ", autodiscover and include can't be used"}
+	}
+	if err != nil {
+		log.Error("error listing files", "error", err)
+		return nil, err
+	}
+	return files, nil
+}
+
+// matchingDirs returns the sorted directories matching the rule's paths and
+// not excluded by it.
+func matchingDirs(rule yaml.AutodiscoverRule, dirs map[string][]string) []string {
+	include := rule.Paths
+	if len(include) == 0 {
+		include = []string{"**"}
+	}
+
+	out := make([]string, 0)
+	for dir := range dirs {
+		if matchAny(include, dir) && !matchAny(rule.Exclude, dir) {
+			out = append(out, dir)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// matchingFiles returns the sorted files matching any of the patterns.
+func matchingFiles(patterns, files []string) []string {
+	out := make([]string, 0)
+	for _, f := range files {
+		if matchAny(patterns, f) {
+			out = append(out, f)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, _ := doublestar.Match(strings.TrimPrefix(p, "./"), name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// filesByDir groups the file names by their directory.
+func filesByDir(files []string) map[string][]string {
+	dirs := make(map[string][]string)
+	for _, f := range files {
+		dir, name := path.Split(f)
+		dir = strings.TrimSuffix(dir, "/")
+		if dir == "" {
+			dir = "."
+		}
+		dirs[dir] = append(dirs[dir], name)
+	}
+	for dir := range dirs {
+		sort.Strings(dirs[dir])
+	}
+	return dirs
+}
+
+func projectKey(dir, workspace string) string {
+	return path.Clean(dir) + ":" + workspace
+}
@@ -0,0 +1,120 @@
+package discovery
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/ivanvc/turnip/internal/yaml"
+)
+
+type fakeSource map[string]string
+
+func (s fakeSource) ListFiles() ([]string, error) {
+	files := make([]string, 0, len(s))
+	for f := range s {
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+func (s fakeSource) FetchFile(path string) ([]byte, error) {
+	data, ok := s[path]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return []byte(data), nil
+}
+
+func TestDiscover(t *testing.T) {
+	src := fakeSource{
+		"README.md":                        "",
+		"stacks/app/Pulumi.yaml":           "name: app",
+		"stacks/app/Pulumi.dev.yaml":       "",
+		"stacks/app/Pulumi.prod.yaml":      "",
+		"stacks/lib/index.ts":              "",
+		"stacks/old/Pulumi.yaml":           "name: old",
+		"stacks/old/Pulumi.dev.yaml":       "",
+		"infra/network/main.tf":            `resource "aws_vpc" "main" {}`,
+		"infra/network/backend.tf":         "terraform {\n  backend \"s3\" {\n  }\n}\n",
+		"infra/dns/main.tf":                "terraform {\n  cloud {\n  }\n}\n",
+		"infra/modules/vpc/main.tf":        `resource "aws_vpc" "main" {}`,
+		"infra/examples/basic/main.tf":     "terraform {\n  backend \"local\" {}\n}\n",
+		"infra/network/templates/user.tpl": "",
+	}
+
+	tests := []struct {
+		name     string
+		projects []yaml.Project
+		rules    []yaml.AutodiscoverRule
+		want     []string
+	}{
+		{
+			name: "no rules",
+			want: []string{},
+		},
+		{
+			name:  "pulumi stacks",
+			rules: []yaml.AutodiscoverRule{{Type: "pulumi", Workflow: "pulumi", Paths: []string{"stacks/**"}, Exclude: []string{"stacks/old"}}},
+			want:  []string{"stacks/app:dev", "stacks/app:prod"},
+		},
+		{
+			name:  "terraform backends",
+			rules: []yaml.AutodiscoverRule{{Type: "terraform", Workflow: "terraform", Paths: []string{"infra/**"}, Exclude: []string{"infra/examples/**"}}},
+			want:  []string{"infra/dns:", "infra/network:"},
+		},
+		{
+			name:     "declared projects take precedence",
+			projects: []yaml.Project{{Dir: "stacks/app", Stack: "dev", Workflow: "pulumi", LoadedWorkflow: yaml.Workflow{Pulumi: &yaml.PulumiAdapter{}}}},
+			rules:    []yaml.AutodiscoverRule{{Type: "pulumi", Workflow: "pulumi", Paths: []string{"./stacks/app"}, AutoPlot: true}},
+			want:     []string{"stacks/app:dev", "stacks/app:prod"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &yaml.Config{
+				Projects: tc.projects,
+				Workflows: map[string]yaml.Workflow{
+					"pulumi":    {Pulumi: &yaml.PulumiAdapter{}},
+					"terraform": {Terraform: &yaml.TerraformAdapter{}},
+				},
+			}
+			if tc.rules != nil {
+				cfg.Autodiscover = &yaml.Autodiscover{Rules: tc.rules}
+			}
+			if err := Discover(cfg, src); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := make([]string, 0, len(cfg.Projects))
+			for _, prj := range cfg.Projects {
+				got = append(got, prj.Dir+":"+prj.Stack)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+			for _, prj := range cfg.Projects[len(tc.projects):] {
+				if prj.AutoPreview != tc.rules[0].AutoPlot {
+					t.Errorf("%s: got AutoPreview %v, want %v", prj.Dir, prj.AutoPreview, tc.rules[0].AutoPlot)
+				}
+			}
+		})
+	}
+}
+
+type truncatedSource struct{ fakeSource }
+
+func (s truncatedSource) ListFiles() ([]string, error) {
+	return nil, fmt.Errorf("listing: %w", ErrTruncated)
+}
+
+func TestDiscoverTruncated(t *testing.T) {
+	cfg := &yaml.Config{Autodiscover: &yaml.Autodiscover{Rules: []yaml.AutodiscoverRule{{Type: "pulumi", Workflow: "pulumi"}}}}
+	err := Discover(cfg, truncatedSource{})
+	var cfgErr *yaml.ConfigError
+	if !errors.As(err, &cfgErr) {
+		t.Errorf("expected a configuration error, got %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package http
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/ivanvc/turnip/internal/adapters/gitlab/handlers"
+	"github.com/ivanvc/turnip/internal/adapters/gitlab/objects"
+)
+
+// gitLabWebhookHandler holds the HTTP endpoint to handle GitLab's webhook.
+type gitLabWebhookHandler struct{}
+
+// Registers the handler to be used by an HTTP server.
+func (h *gitLabWebhookHandler) registerHandler(s *Server) {
+	http.HandleFunc("/webhooks/gitlab/payload", h.handle(s))
+}
+
+// Handles the HTTP request, after verifying its secret token.
+func (h *gitLabWebhookHandler) handle(s *Server) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		token := req.Header.Get("X-Gitlab-Token")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.Config.GitLabWebhookSecret)) != 1 {
+			log.Warn("Invalid GitLab webhook token", "remote", req.RemoteAddr)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch req.Header.Get("X-Gitlab-Event") {
+		case "Note Hook":
+			log.Info("Handling note")
+			var note objects.NoteWebhook
+			if err := json.NewDecoder(req.Body).Decode(&note); err != nil {
+				log.Error("Error unmarshalling", "error", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			if err := handlers.HandleNote(s.Common, &note); err != nil {
+				log.Error("Error handling note", "error", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		case "Merge Request Hook":
+			var mr objects.MergeRequestWebhook
+			if err := json.NewDecoder(req.Body).Decode(&mr); err != nil {
+				log.Error("Error unmarshalling", "error", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			log.Info("Handling merge request", "merge_request", mr.ObjectAttributes.URL)
+			if err := handlers.HandleMergeRequest(s.Common, &mr); err != nil {
+				log.Error("Error handling merge request", "error", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
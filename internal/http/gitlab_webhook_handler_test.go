@@ -0,0 +1,35 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ivanvc/turnip/internal/common"
+	"github.com/ivanvc/turnip/internal/config"
+)
+
+func TestGitLabWebhookToken(t *testing.T) {
+	s := &Server{Common: &common.Common{Config: &config.Config{GitLabWebhookSecret: "s3cret"}}}
+	tt := []struct {
+		token    string
+		expected int
+	}{
+		{"s3cret", http.StatusOK},
+		{"wrong", http.StatusUnauthorized},
+		{"", http.StatusUnauthorized},
+	}
+	for _, tc := range tt {
+		t.Run(tc.token, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/gitlab/payload", strings.NewReader("{}"))
+			req.Header.Set("X-Gitlab-Token", tc.token)
+			req.Header.Set("X-Gitlab-Event", "Push Hook")
+			w := httptest.NewRecorder()
+			new(gitLabWebhookHandler).handle(s)(w, req)
+			if w.Code != tc.expected {
+				t.Errorf("expected status %d, got %d", tc.expected, w.Code)
+			}
+		})
+	}
+}
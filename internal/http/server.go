@@ -40,4 +40,7 @@ func (s *Server) registerHandlers() {
 	new(statusHandler).registerHandler()
 	new(webhookHandler).registerHandler(s)
 	new(apiHandler).registerHandler(s)
+	if s.GitLabClient != nil {
+		new(gitLabWebhookHandler).registerHandler(s)
+	}
 }
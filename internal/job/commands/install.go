@@ -9,22 +9,34 @@ import (
 )
 
 func Install(dir, repoDir string, project yaml.Project) ([]byte, error) {
-	p := plugin.Load(project)
+	p, err := plugin.Load(project)
+	if err != nil {
+		return []byte{}, err
+	}
 	return p.InstallDependencies(dir, repoDir)
 }
 
 func Plot(repoDir string, project yaml.Project, extraArgs string) (bool, []byte, error) {
-	p := plugin.Load(project)
+	p, err := plugin.Load(project)
+	if err != nil {
+		return false, []byte{}, err
+	}
 	return p.Plot(repoDir, extraArgs)
 }
 
 func Lift(repoDir string, project yaml.Project, extraArgs string) (bool, []byte, error) {
-	p := plugin.Load(project)
+	p, err := plugin.Load(project)
+	if err != nil {
+		return false, []byte{}, err
+	}
 	return p.Lift(repoDir, extraArgs)
 }
 
 func RunInitCommands(repoDir string, project yaml.Project) ([]byte, error) {
-	p := plugin.Load(project)
+	p, err := plugin.Load(project)
+	if err != nil {
+		return []byte{}, err
+	}
 	w := project.LoadedWorkflow
 	log.Info("checking if there are pre-commands to run", "project", project, "workflow", w)
 	if len(w.InitCommands) == 0 {
@@ -0,0 +1,131 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/ivanvc/turnip/internal/job/plugin"
+	"github.com/ivanvc/turnip/internal/yaml"
+)
+
+// RunJob installs the project's tools, runs the workflow's init commands, and
+// then the command, in the repository checked out in repoDir. tmpDir holds
+// the downloads and the files passed to the hooks.
+func RunJob(tmpDir, repoDir, command string, project yaml.Project, extraArgs string) (bool, []byte, error) {
+	if output, err := Install(tmpDir, repoDir, project); err != nil {
+		log.Error("error installing dependencies", "error", err)
+		return false, output, err
+	}
+
+	output, err := RunInitCommands(repoDir, project)
+	if err != nil {
+		log.Error("error running pre commands", "error", err, "output", string(output))
+		return false, output, err
+	}
+
+	return Run(tmpDir, repoDir, command, project, extraArgs)
+}
+
+// Run runs the command, either plot or lift, between its pre and post hooks.
+// The post hooks run even if the command fails, and get its output, status and
+// exit code in their environment. A failing hook fails the job. The output of
+// a failing pre hook is reported as the job's, and the one of a failing post
+// hook in the error.
+func Run(tmpDir, repoDir, command string, project yaml.Project, extraArgs string) (bool, []byte, error) {
+	var pre, post []yaml.Command
+	var preName, postName string
+	var run func(string, yaml.Project, string) (bool, []byte, error)
+	switch command {
+	case "plot":
+		pre, post, run = project.LoadedWorkflow.PrePlot, project.LoadedWorkflow.PostPlot, Plot
+		preName, postName = "prePlot", "postPlot"
+	case "lift":
+		pre, post, run = project.LoadedWorkflow.PreLift, project.LoadedWorkflow.PostLift, Lift
+		preName, postName = "preLift", "postLift"
+	default:
+		return false, []byte{}, fmt.Errorf("unknown command %s", command)
+	}
+
+	dir := filepath.Join(repoDir, project.Dir)
+	env := hookEnv(repoDir, command, project)
+
+	if out, err := plugin.RunHook(dir, project, pre, env); err != nil {
+		log.Error("error running "+preName+" hook", "error", err)
+		return false, out, fmt.Errorf("%s hook failed: %w", preName, err)
+	}
+
+	finishedWithError, output, err := run(repoDir, project, extraArgs)
+	if err != nil {
+		log.Error("error running "+command, "error", err)
+	}
+	if len(post) == 0 {
+		return finishedWithError, output, err
+	}
+
+	outputFile := filepath.Join(tmpDir, command+".out")
+	if werr := os.WriteFile(outputFile, output, 0600); werr != nil {
+		log.Error("error writing output file", "error", werr)
+		return finishedWithError, output, werr
+	}
+	status := "succeeded"
+	if err != nil || finishedWithError {
+		status = "failed"
+	}
+	env = append(
+		env,
+		"TURNIP_OUTPUT_FILE="+outputFile,
+		"TURNIP_STATUS="+status,
+		"TURNIP_EXIT_CODE="+strconv.Itoa(exitCode(finishedWithError, err)),
+	)
+	if err != nil {
+		env = append(env, "TURNIP_ERROR="+err.Error())
+	}
+
+	if out, herr := plugin.RunHook(dir, project, post, env); herr != nil {
+		log.Error("error running "+postName+" hook", "error", herr)
+		if err != nil {
+			return finishedWithError, output, fmt.Errorf("%w\n\n%w", err, hookError(postName, out, herr))
+		}
+		return finishedWithError, output, hookError(postName, out, herr)
+	}
+	return finishedWithError, output, err
+}
+
+// hookEnv returns the environment with the project's metadata for the hooks.
+func hookEnv(repoDir, command string, project yaml.Project) []string {
+	return []string{
+		"TURNIP_COMMAND=" + command,
+		"TURNIP_REPO_DIR=" + repoDir,
+		"TURNIP_PROJECT_NAME=" + project.Name,
+		"TURNIP_PROJECT_DIR=" + project.Dir,
+		"TURNIP_PROJECT_WORKSPACE=" + project.GetWorkspace(),
+		"TURNIP_ADAPTER=" + project.GetAdapterName(),
+	}
+}
+
+// exitCode returns the exit code of the command, or 1 if it failed without
+// exiting with one, e.g. it couldn't be started.
+func exitCode(finishedWithError bool, err error) int {
+	var exitErr *exec.ExitError
+	var pluginErr *plugin.ExitError
+	switch {
+	case errors.As(err, &exitErr) && exitErr.ExitCode() >= 0:
+		return exitErr.ExitCode()
+	case errors.As(err, &pluginErr):
+		return pluginErr.Code
+	case err != nil || finishedWithError:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func hookError(stage string, output []byte, err error) error {
+	return fmt.Errorf("%s hook failed: %w\n%s", stage, err, output)
+}
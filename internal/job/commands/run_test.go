@@ -0,0 +1,92 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ivanvc/turnip/internal/yaml"
+)
+
+func TestRunHooks(t *testing.T) {
+	tt := []struct {
+		name        string
+		workflow    yaml.Workflow
+		expectedErr string
+	}{
+		{
+			"hooks succeed",
+			yaml.Workflow{PrePlot: []yaml.Command{{Run: "true"}}, PostPlot: []yaml.Command{{Run: "true"}}},
+			"",
+		},
+		{
+			"pre hook fails",
+			yaml.Workflow{PrePlot: []yaml.Command{{Run: "false"}}},
+			"prePlot hook failed",
+		},
+		{
+			"post hook fails",
+			yaml.Workflow{PostPlot: []yaml.Command{{Run: "false"}}},
+			"postPlot hook failed",
+		},
+		{
+			"lift hooks are not run on plot",
+			yaml.Workflow{PreLift: []yaml.Command{{Run: "false"}}},
+			"",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			repoDir := t.TempDir()
+			if err := os.Mkdir(filepath.Join(repoDir, "app"), 0750); err != nil {
+				t.Fatal(err)
+			}
+			tc.workflow.Custom = &yaml.CustomAdapter{Plot: []yaml.Command{{Run: "true"}}, Lift: []yaml.Command{{Run: "true"}}}
+			project := yaml.Project{Dir: "app", LoadedWorkflow: tc.workflow}
+
+			_, _, err := Run(t.TempDir(), repoDir, "plot", project, "")
+			switch {
+			case tc.expectedErr == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tc.expectedErr != "" && (err == nil || !strings.Contains(err.Error(), tc.expectedErr)):
+				t.Errorf("expected error %q, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestRunPostHookEnv(t *testing.T) {
+	repoDir := t.TempDir()
+	envFile := filepath.Join(t.TempDir(), "env")
+	project := yaml.Project{LoadedWorkflow: yaml.Workflow{
+		Custom:   &yaml.CustomAdapter{Plot: []yaml.Command{{Run: "exit 3"}}, Lift: []yaml.Command{{Run: "true"}}},
+		PostPlot: []yaml.Command{{Run: `echo "$TURNIP_STATUS $TURNIP_EXIT_CODE" > ` + envFile}},
+	}}
+
+	if _, _, err := Run(t.TempDir(), repoDir, "plot", project, ""); err == nil {
+		t.Error("expected the plot to fail")
+	}
+	data, err := os.ReadFile(envFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "failed 3\n" {
+		t.Errorf("expected the status and exit code, got %q", data)
+	}
+}
+
+func TestRunPreHookOutput(t *testing.T) {
+	project := yaml.Project{LoadedWorkflow: yaml.Workflow{
+		Custom:  &yaml.CustomAdapter{Plot: []yaml.Command{{Run: "echo plot"}}, Lift: []yaml.Command{{Run: "true"}}},
+		PrePlot: []yaml.Command{{Run: "echo missing credentials; false"}},
+	}}
+
+	_, output, err := Run(t.TempDir(), t.TempDir(), "plot", project, "")
+	if err == nil {
+		t.Error("expected the pre hook to fail")
+	}
+	if string(output) != "missing credentials\n" {
+		t.Errorf("expected the hook's output, got %q", output)
+	}
+}
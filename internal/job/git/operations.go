@@ -9,9 +9,9 @@ import (
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
 )
 
-func Clone(dir, cloneURL, baseRef, token string) error {
+func Clone(dir, cloneURL, baseRef, username, token string) error {
 	auth := &http.BasicAuth{
-		Username: "token",
+		Username: username,
 		Password: token,
 	}
 
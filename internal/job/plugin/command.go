@@ -0,0 +1,156 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/ivanvc/turnip/internal/yaml"
+)
+
+// waitDelay is how long to wait for the output after a command is killed.
+const waitDelay = 5 * time.Second
+
+type runOptions struct {
+	// toolCommand returns the command line for the tool specific commands, or
+	// nil if the command doesn't set one.
+	toolCommand func(yaml.Command) []string
+	// okExitCodes are, besides 0, the exit codes considered successful.
+	okExitCodes []int
+	// env is added to the environment of every command, before the command's
+	// own env.
+	env []string
+	// args are appended to the last command as separate arguments. They are
+	// never parsed by the shell, as they may come from a comment.
+	args []string
+}
+
+// runCommands runs the commands in dir, stopping at the first one that fails,
+// unless it's set to continue on error. It returns the exit code of the last
+// command run.
+func runCommands(dir string, proj yaml.Project, cmds []yaml.Command, opts runOptions) ([]byte, int, error) {
+	output := make([]byte, 0)
+	exitCode := 0
+
+	for i, cmd := range cmds {
+		cmdOpts := opts
+		if i < len(cmds)-1 {
+			cmdOpts.args = nil
+		}
+		out, code, err := runCommand(dir, proj, cmd, cmdOpts)
+		if !cmd.OmitOutput {
+			output = append(output, out...)
+		}
+		if code < 0 && err == nil {
+			// Nothing to run.
+			continue
+		}
+		exitCode = code
+		if err != nil {
+			if cmd.ContinueOnError {
+				log.Warn("command failed, continuing", "err", err)
+				continue
+			}
+			return output, exitCode, err
+		}
+	}
+
+	return output, exitCode, nil
+}
+
+// ExitError is returned when a command exits with an unexpected code.
+type ExitError struct {
+	Command string
+	Code    int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("command %s exited with code %d", e.Command, e.Code)
+}
+
+// runCommand runs a single command. It returns -1 as the exit code if there's
+// nothing to run, or the command couldn't be started.
+func runCommand(dir string, proj yaml.Project, cmd yaml.Command, opts runOptions) ([]byte, int, error) {
+	args := commandArgs(cmd, opts.toolCommand, opts.args)
+	if len(args) == 0 {
+		return []byte{}, -1, nil
+	}
+
+	ctx := context.Background()
+	if cmd.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cmd.Timeout)
+		defer cancel()
+	}
+
+	c := exec.CommandContext(ctx, args[0], args[1:]...)
+	setKillGroup(c)
+	// Don't wait forever for children holding the output of a killed command.
+	c.WaitDelay = waitDelay
+	c.Dir = dir
+	if cmd.WorkingDir != "" {
+		c.Dir = filepath.Join(dir, cmd.WorkingDir)
+	}
+	c.Env = commandEnv(c.Environ(), proj, cmd, opts.env)
+	log.Info("running command", "cmd", c, "dir", c.Dir)
+
+	out, err := c.CombinedOutput()
+	log.Info("command output", "output", string(out))
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return out, -1, fmt.Errorf("command %s timed out after %s", cmd.Run, cmd.Timeout)
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return out, 0, nil
+	case errors.As(err, &exitErr):
+		code := exitErr.ExitCode()
+		if slices.Contains(opts.okExitCodes, code) {
+			return out, code, nil
+		}
+		log.Error("error running command", "err", err)
+		return out, code, &ExitError{Command: strings.Join(args, " "), Code: code}
+	default:
+		log.Error("error running command", "err", err)
+		return out, -1, err
+	}
+}
+
+// commandArgs returns the command line to run, followed by extraArgs. Run is
+// executed by the command's shell, unless it's set to none. The tool specific
+// commands are never run through a shell. In a shell, extraArgs are passed as
+// its positional parameters, so they are never interpreted.
+func commandArgs(cmd yaml.Command, toolCommand func(yaml.Command) []string, extraArgs []string) []string {
+	switch {
+	case cmd.Run == "" && toolCommand != nil:
+		return append(toolCommand(cmd), extraArgs...)
+	case cmd.Run == "":
+		return nil
+	case cmd.GetShell() == "":
+		return append(strings.Fields(cmd.Run), extraArgs...)
+	case len(extraArgs) == 0:
+		return []string{cmd.GetShell(), "-c", cmd.Run}
+	default:
+		run := strings.TrimRight(cmd.Run, " \t\n") + ` "$@"`
+		return append([]string{cmd.GetShell(), "-c", run, cmd.GetShell()}, extraArgs...)
+	}
+}
+
+// commandEnv returns the environment of the command. The later values take
+// precedence: the process' environment, then the workflow's, the project's,
+// the extra env, and finally the command's.
+func commandEnv(base []string, proj yaml.Project, cmd yaml.Command, extra []string) []string {
+	env := slices.Clone(base)
+	env = append(env, proj.LoadedWorkflow.GetEnv()...)
+	env = append(env, proj.GetEnv()...)
+	env = append(env, extra...)
+	return append(env, cmd.GetEnv()...)
+}
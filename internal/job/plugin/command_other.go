@@ -0,0 +1,9 @@
+//go:build !unix
+
+package plugin
+
+import "os/exec"
+
+// setKillGroup is a no-op, only the command's process is killed when
+// canceled.
+func setKillGroup(c *exec.Cmd) {}
@@ -0,0 +1,96 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivanvc/turnip/internal/yaml"
+)
+
+func TestRunCommands(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0750); err != nil {
+		t.Fatal(err)
+	}
+	project := yaml.Project{
+		Env: map[string]string{"LEVEL": "project", "PROJECT": "p"},
+		LoadedWorkflow: yaml.Workflow{
+			Env: map[string]string{"LEVEL": "workflow", "WORKFLOW": "w"},
+		},
+	}
+
+	tt := []struct {
+		name      string
+		cmds      []yaml.Command
+		expected  string
+		expectErr bool
+	}{
+		{
+			"shell quotes and pipes",
+			[]yaml.Command{{Run: `echo "a  b" | tr a-z A-Z && echo $WORKFLOW`}},
+			"A  B\nw\n",
+			false,
+		},
+		{
+			"no shell",
+			[]yaml.Command{{Run: `echo "a  b"`, Shell: yaml.NoShell}},
+			"\"a b\"\n",
+			false,
+		},
+		{
+			"working dir",
+			[]yaml.Command{{Run: "basename $(pwd)", WorkingDir: "sub"}},
+			"sub\n",
+			false,
+		},
+		{
+			"env precedence",
+			[]yaml.Command{
+				{Run: "echo $LEVEL $PROJECT $WORKFLOW"},
+				{Run: "echo $LEVEL", Env: map[string]string{"LEVEL": "command"}},
+			},
+			"project p w\ncommand\n",
+			false,
+		},
+		{
+			"stops on error",
+			[]yaml.Command{{Run: "echo one; exit 1"}, {Run: "echo two"}},
+			"one\n",
+			true,
+		},
+		{
+			"continue on error",
+			[]yaml.Command{{Run: "echo one; exit 1", ContinueOnError: true}, {Run: "echo two"}},
+			"one\ntwo\n",
+			false,
+		},
+		{
+			"timeout",
+			[]yaml.Command{{Run: "sleep 5", Timeout: 100 * time.Millisecond}},
+			"",
+			true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("LEVEL", "global")
+			out, _, err := runCommands(dir, project, tc.cmds, runOptions{})
+			if tc.expectErr != (err != nil) {
+				t.Errorf("expected error %v, got %v", tc.expectErr, err)
+			}
+			if string(out) != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, string(out))
+			}
+		})
+	}
+}
+
+func TestRunCommandsTimeoutError(t *testing.T) {
+	_, _, err := runCommands(t.TempDir(), yaml.Project{}, []yaml.Command{{Run: "sleep 5", Timeout: 50 * time.Millisecond}}, runOptions{})
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected a timeout error, got %v", err)
+	}
+}
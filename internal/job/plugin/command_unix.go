@@ -0,0 +1,17 @@
+//go:build unix
+
+package plugin
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setKillGroup makes the command kill its whole process group when canceled,
+// so the children of a shell don't outlive it.
+func setKillGroup(c *exec.Cmd) {
+	c.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	c.Cancel = func() error {
+		return syscall.Kill(-c.Process.Pid, syscall.SIGKILL)
+	}
+}
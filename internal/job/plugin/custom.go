@@ -0,0 +1,68 @@
+package plugin
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/log"
+
+	intyaml "github.com/ivanvc/turnip/internal/yaml"
+)
+
+// Custom runs the commands declared in turnip.yaml.
+type Custom struct {
+	project intyaml.Project
+	adapter intyaml.CustomAdapter
+}
+
+func init() {
+	register(intyaml.CustomAdapter{}, func(p intyaml.Project) Plugin { return NewCustom(p) })
+}
+
+// NewCustom returns the plugin for a project with a custom adapter.
+func NewCustom(project intyaml.Project) Custom {
+	var a intyaml.CustomAdapter
+	if project.LoadedWorkflow.Custom != nil {
+		a = *project.LoadedWorkflow.Custom
+	}
+	return Custom{project: project, adapter: a}
+}
+
+func (c Custom) InstallDependencies(dest, repoDir string) ([]byte, error) {
+	output, _, err := runCommands(filepath.Join(repoDir, c.project.Dir), c.project, c.adapter.Install, runOptions{})
+	return output, err
+}
+
+// Plot runs the plot commands. The exit codes telling whether there are
+// changes are not errors, and are reported at the end of the output.
+func (c Custom) Plot(repoDir, extraArgs string) (bool, []byte, error) {
+	codes := c.adapter.ExitCodes
+	output, exitCode, err := runCommands(
+		filepath.Join(repoDir, c.project.Dir),
+		c.project,
+		c.adapter.Plot,
+		runOptions{okExitCodes: slices.Concat(codes.Changes, codes.NoChanges), args: strings.Fields(extraArgs)},
+	)
+	if err != nil {
+		return false, output, err
+	}
+	changes := slices.Contains(codes.Changes, exitCode)
+	log.Info("plot finished", "exitCode", exitCode, "changes", changes)
+	switch {
+	case changes:
+		output = append(output, "\nChanges present.\n"...)
+	case slices.Contains(codes.NoChanges, exitCode):
+		output = append(output, "\nNo changes.\n"...)
+	}
+	return false, output, nil
+}
+
+func (c Custom) Lift(repoDir, extraArgs string) (bool, []byte, error) {
+	output, _, err := runCommands(filepath.Join(repoDir, c.project.Dir), c.project, c.adapter.Lift, runOptions{args: strings.Fields(extraArgs)})
+	return false, output, err
+}
+
+func (c Custom) RunInitCommands(dir string) ([]byte, error) {
+	return runInitCommands(dir, c.project, nil)
+}
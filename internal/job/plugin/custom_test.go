@@ -0,0 +1,83 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	intyaml "github.com/ivanvc/turnip/internal/yaml"
+)
+
+func TestCustomPlot(t *testing.T) {
+	repoDir := t.TempDir()
+	if err := os.Mkdir(repoDir+"/app", 0750); err != nil {
+		t.Fatal(err)
+	}
+
+	tt := []struct {
+		name      string
+		run       string
+		exitCodes intyaml.ExitCodes
+		expectErr bool
+		expected  string
+	}{
+		{"success", "echo plan", intyaml.ExitCodes{}, false, "plan\n"},
+		{"changes exit code", "echo plan; exit 2", intyaml.ExitCodes{Changes: []int{2}, NoChanges: []int{0}}, false, "plan\n\nChanges present.\n"},
+		{"no changes exit code", "echo plan", intyaml.ExitCodes{Changes: []int{2}, NoChanges: []int{0}}, false, "plan\n\nNo changes.\n"},
+		{"unexpected exit code", "false", intyaml.ExitCodes{Changes: []int{2}}, true, ""},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			p := NewCustom(intyaml.Project{
+				Dir: "app",
+				LoadedWorkflow: intyaml.Workflow{Custom: &intyaml.CustomAdapter{
+					Plot:      []intyaml.Command{{Run: tc.run}},
+					ExitCodes: tc.exitCodes,
+				}},
+			})
+			_, output, err := p.Plot(repoDir, "")
+			if tc.expectErr != (err != nil) {
+				t.Errorf("expected error %v, got %v", tc.expectErr, err)
+			}
+			if !tc.expectErr && string(output) != tc.expected {
+				t.Errorf("expected output %q, got %q", tc.expected, output)
+			}
+		})
+	}
+}
+
+func TestCustomPlotExtraArgs(t *testing.T) {
+	repoDir := t.TempDir()
+
+	tt := []struct {
+		name  string
+		shell string
+		run   string
+	}{
+		{"shell", "", "echo deps\nprintf '%s\\n'\n"},
+		{"no shell", intyaml.NoShell, "printf %s\\n"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			p := NewCustom(intyaml.Project{
+				LoadedWorkflow: intyaml.Workflow{Custom: &intyaml.CustomAdapter{
+					Plot: []intyaml.Command{{Run: "echo first"}, {Run: tc.run, Shell: tc.shell}},
+				}},
+			})
+			_, output, err := p.Plot(repoDir, "TARGET=web ; touch pwned $(id) `id`")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.HasSuffix(string(output), "TARGET=web\n;\ntouch\npwned\n$(id)\n`id`\n") {
+				t.Errorf("expected the extra args to be literal arguments of the last command, got %q", output)
+			}
+			if !strings.HasPrefix(string(output), "first\n") {
+				t.Errorf("expected the first command to run without the extra args, got %q", output)
+			}
+			if _, err := os.Stat(filepath.Join(repoDir, "pwned")); err == nil {
+				t.Error("expected the extra args not to be run")
+			}
+		})
+	}
+}
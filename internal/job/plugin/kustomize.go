@@ -0,0 +1,157 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/log"
+
+	intyaml "github.com/ivanvc/turnip/internal/yaml"
+)
+
+const kubectlDownloadURL = "https://dl.k8s.io/release/v%s/bin/linux/amd64/kubectl"
+
+// fieldManager is the name of the manager of the fields applied server-side.
+const fieldManager = "turnip"
+
+// kustomizationFiles are the files that make a directory a kustomization.
+var kustomizationFiles = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+// Kustomize runs kubectl on a kustomization, or a plain manifests directory.
+type Kustomize struct {
+	project intyaml.Project
+	adapter intyaml.KustomizeAdapter
+}
+
+func init() {
+	register(intyaml.KustomizeAdapter{}, func(p intyaml.Project) Plugin { return NewKustomize(p) })
+}
+
+// NewKustomize returns the plugin for a Kustomize project.
+func NewKustomize(project intyaml.Project) Kustomize {
+	var a intyaml.KustomizeAdapter
+	if project.LoadedWorkflow.Kustomize != nil {
+		a = *project.LoadedWorkflow.Kustomize
+	}
+	return Kustomize{project: project, adapter: a}
+}
+
+func (k Kustomize) InstallDependencies(dest, repoDir string) ([]byte, error) {
+	if k.adapter.SkipInstall {
+		log.Info("skipping install", "binary", "kubectl")
+		return []byte{}, nil
+	}
+
+	version, err := resolveVersion(filepath.Join(repoDir, k.project.Dir), k.adapter.Version, k.adapter.VersionFrom, ".kubectl-version")
+	if err != nil {
+		log.Error("error getting version", "err", err)
+		return []byte{}, err
+	}
+
+	resp, err := http.Get(fmt.Sprintf(kubectlDownloadURL, version))
+	if err != nil {
+		log.Error("error downloading", "err", err)
+		return []byte{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return []byte{}, fmt.Errorf("error downloading kubectl %s: %s", version, resp.Status)
+	}
+
+	out, err := os.OpenFile(filepath.Join(BinDir, "kubectl"), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0755)
+	if err != nil {
+		log.Error("error creating file", "err", err)
+		return []byte{}, err
+	}
+	defer out.Close()
+
+	if _, err = io.Copy(out, resp.Body); err != nil {
+		log.Error("error writing download", "err", err)
+		return []byte{}, err
+	}
+
+	return []byte{}, nil
+}
+
+// Plot runs a server-side diff. kubectl exits with 1 when there are
+// differences, so only greater exit codes are errors.
+func (k Kustomize) Plot(repoDir, extraArgs string) (bool, []byte, error) {
+	cmd := k.command(repoDir, k.args(repoDir, "diff", extraArgs)...)
+	log.Debug("running kubectl diff", "cmd", cmd)
+
+	out, err := cmd.CombinedOutput()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return false, out, nil
+	}
+	if err != nil {
+		log.Error("error running kubectl diff", "err", err, "output", string(out))
+		return false, out, err
+	}
+	return false, out, nil
+}
+
+func (k Kustomize) Lift(repoDir, extraArgs string) (bool, []byte, error) {
+	cmd := k.command(repoDir, k.args(repoDir, "apply", extraArgs)...)
+	log.Debug("running kubectl apply", "cmd", cmd)
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Error("error running kubectl apply", "err", err, "output", string(out))
+		return false, out, err
+	}
+	return false, out, nil
+}
+
+// args returns the kubectl arguments for the command, either diff or apply.
+func (k Kustomize) args(repoDir, command, extraArgs string) []string {
+	args := []string{command, "--server-side", "--field-manager=" + fieldManager}
+	if context := k.project.GetWorkspace(); context != "" {
+		args = append(args, "--context", context)
+	}
+	if k.adapter.Namespace != "" {
+		args = append(args, "--namespace", k.adapter.Namespace)
+	}
+
+	if isKustomization(filepath.Join(repoDir, k.project.Dir)) {
+		args = append(args, "--kustomize", ".")
+	} else {
+		args = append(args, "--filename", ".", "--recursive")
+	}
+
+	if command == "apply" && k.adapter.Prune {
+		args = append(args, "--prune")
+		if k.adapter.PruneSelector != "" {
+			args = append(args, "--selector", k.adapter.PruneSelector)
+		} else {
+			args = append(args, "--all")
+		}
+	}
+
+	return append(args, strings.Fields(extraArgs)...)
+}
+
+func isKustomization(dir string) bool {
+	for _, f := range kustomizationFiles {
+		if _, err := os.Stat(filepath.Join(dir, f)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (k Kustomize) command(repoDir string, args ...string) *exec.Cmd {
+	cmd := exec.Command("kubectl", args...)
+	cmd.Dir = filepath.Join(repoDir, k.project.Dir)
+	return cmd
+}
+
+func (k Kustomize) RunInitCommands(dir string) ([]byte, error) {
+	return runInitCommands(dir, k.project, nil)
+}
@@ -0,0 +1,62 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	intyaml "github.com/ivanvc/turnip/internal/yaml"
+)
+
+func TestKustomizeArgs(t *testing.T) {
+	repoDir := t.TempDir()
+	for _, dir := range []string{"overlays/prod", "manifests"} {
+		if err := os.MkdirAll(filepath.Join(repoDir, dir), 0750); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(repoDir, "overlays/prod", "kustomization.yaml"), []byte("resources: []\n"), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	tt := []struct {
+		name     string
+		project  intyaml.Project
+		command  string
+		expected []string
+	}{
+		{
+			"kustomization diff",
+			intyaml.Project{Dir: "overlays/prod", LoadedWorkflow: intyaml.Workflow{Kustomize: &intyaml.KustomizeAdapter{Context: "prod", Namespace: "app"}}},
+			"diff",
+			[]string{"diff", "--server-side", "--field-manager=turnip", "--context", "prod", "--namespace", "app", "--kustomize", "."},
+		},
+		{
+			"manifests apply with workspace",
+			intyaml.Project{Dir: "manifests", Workspace: "stg", LoadedWorkflow: intyaml.Workflow{Kustomize: &intyaml.KustomizeAdapter{Context: "prod"}}},
+			"apply",
+			[]string{"apply", "--server-side", "--field-manager=turnip", "--context", "stg", "--filename", ".", "--recursive"},
+		},
+		{
+			"prune with selector",
+			intyaml.Project{Dir: "overlays/prod", LoadedWorkflow: intyaml.Workflow{Kustomize: &intyaml.KustomizeAdapter{Prune: true, PruneSelector: "app=web"}}},
+			"apply",
+			[]string{"apply", "--server-side", "--field-manager=turnip", "--kustomize", ".", "--prune", "--selector", "app=web"},
+		},
+		{
+			"prune is only applied on apply",
+			intyaml.Project{Dir: "overlays/prod", LoadedWorkflow: intyaml.Workflow{Kustomize: &intyaml.KustomizeAdapter{Prune: true}}},
+			"diff",
+			[]string{"diff", "--server-side", "--field-manager=turnip", "--kustomize", "."},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := NewKustomize(tc.project).args(repoDir, tc.command, "")
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -1,6 +1,14 @@
 package plugin
 
-import "github.com/ivanvc/turnip/internal/yaml"
+import (
+	"fmt"
+
+	"github.com/ivanvc/turnip/internal/yaml"
+)
+
+// BinDir is the directory the tools are installed to. It has to be in the
+// PATH of the commands.
+var BinDir = "/opt/turnip/bin"
 
 type Plugin interface {
 	// PlanCommand returns the command to run to plan the project.
@@ -20,15 +28,38 @@ type Plugin interface {
 	RunInitCommands(string) ([]byte, error)
 }
 
-func Load(project yaml.Project) Plugin {
+// loaders returns the plugin for a project, by the name of its adapter.
+var loaders = make(map[string]func(yaml.Project) Plugin)
+
+// register adds the loader of the plugin for the adapter.
+func register(adapter yaml.Adapter, load func(yaml.Project) Plugin) {
+	loaders[adapter.GetName()] = load
+}
+
+// Load returns the plugin for the project's adapter.
+func Load(project yaml.Project) (Plugin, error) {
 	a, err := project.LoadedWorkflow.GetAdapter()
 	if err != nil {
-		return nil
+		return nil, err
 	}
-	switch a.GetName() {
-	case "pulumi":
-		return Pulumi{project: project}
-	default:
-		return nil
+	load, ok := loaders[a.GetName()]
+	if !ok {
+		return nil, fmt.Errorf("unsupported adapter %s", a.GetName())
 	}
+	return load(project), nil
+}
+
+// runInitCommands runs the workflow's init commands in dir. toolCommand returns
+// the command line for the tool specific commands, or nil if the command
+// doesn't set one.
+func runInitCommands(dir string, proj yaml.Project, toolCommand func(yaml.Command) []string) ([]byte, error) {
+	output, _, err := runCommands(dir, proj, proj.LoadedWorkflow.InitCommands, runOptions{toolCommand: toolCommand})
+	return output, err
+}
+
+// RunHook runs the commands of a workflow stage in dir, with env added to
+// their environment.
+func RunHook(dir string, proj yaml.Project, cmds []yaml.Command, env []string) ([]byte, error) {
+	output, _, err := runCommands(dir, proj, cmds, runOptions{env: env})
+	return output, err
 }
@@ -0,0 +1,16 @@
+package plugin
+
+import (
+	"testing"
+
+	intyaml "github.com/ivanvc/turnip/internal/yaml"
+)
+
+func TestLoad(t *testing.T) {
+	if _, err := Load(intyaml.Project{LoadedWorkflow: intyaml.Workflow{Custom: &intyaml.CustomAdapter{}}}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := Load(intyaml.Project{LoadedWorkflow: intyaml.Workflow{Helmfile: &intyaml.HelmfileAdapter{}}}); err == nil {
+		t.Error("expected an error for an adapter without a runner plugin")
+	}
+}
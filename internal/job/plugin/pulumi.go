@@ -3,6 +3,7 @@ package plugin
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -23,6 +24,10 @@ type Pulumi struct {
 	execPath string
 }
 
+func init() {
+	register(intyaml.PulumiAdapter{}, func(p intyaml.Project) Plugin { return Pulumi{project: p} })
+}
+
 const downloadURL = "https://github.com/pulumi/pulumi/releases/download/v%s/pulumi-v%s-linux-x64.tar.gz"
 
 func (p Pulumi) InstallDependencies(dest, repoDir string) ([]byte, error) {
@@ -81,7 +86,7 @@ func (p Pulumi) InstallDependencies(dest, repoDir string) ([]byte, error) {
 		return output.Bytes(), err
 	}
 	for _, file := range files {
-		if err := copyFile(file, "/opt/turnip/bin"); err != nil {
+		if err := copyFile(file, BinDir); err != nil {
 			log.Error("error copying", "err", err)
 			return output.Bytes(), err
 		}
@@ -194,11 +199,18 @@ func (p Pulumi) runCommand(command, repoDir, extraArgs string) (bool, []byte, er
 		"--color",
 		"never",
 		command,
-		"--diff",
 		"--stack",
 		p.project.Stack,
 	}
 
+	// Previews are reported as JSON, so the server can render them.
+	jsonOutput := command == "preview"
+	if jsonOutput {
+		args = append(args, "--json")
+	} else {
+		args = append(args, "--diff")
+	}
+
 	if command == "up" {
 		args = append(args, "--yes")
 		args = append(args, "--skip-preview")
@@ -206,22 +218,34 @@ func (p Pulumi) runCommand(command, repoDir, extraArgs string) (bool, []byte, er
 
 	args = append(args, strings.Fields(extraArgs)...)
 
+	stderr := new(bytes.Buffer)
 	cmd := exec.Command("pulumi", args...)
 	cmd.Stdout = output
 	cmd.Stderr = output
+	if jsonOutput {
+		cmd.Stderr = stderr
+	}
 
-	log.Debug("running pulumi preview", "cmd", cmd)
+	log.Debug("running pulumi "+command, "cmd", cmd)
 
 	if err := cmd.Run(); err != nil {
-		log.Error("error running pulumi preview", "err", err, "output", output.String())
+		log.Error("error running pulumi "+command, "err", err, "output", output.String(), "stderr", stderr.String())
+		// The JSON output includes the diagnostics, otherwise report
+		// everything that was printed.
+		if jsonOutput && !json.Valid(output.Bytes()) {
+			output.Write(stderr.Bytes())
+		}
 		return false, output.Bytes(), err
 	}
-	log.Debug("pulumi preview output", "exitCode", cmd.ProcessState.ExitCode())
+	log.Debug("pulumi "+command+" output", "exitCode", cmd.ProcessState.ExitCode())
 
 	if cmd.ProcessState.ExitCode() != 0 {
 		return true, output.Bytes(), nil
 	}
 
+	if jsonOutput {
+		return false, output.Bytes(), nil
+	}
 	return false, processOutput(output.Bytes()), nil
 }
 
@@ -274,44 +298,12 @@ outterloop:
 	return fmt.Sprintf("%s%s%s", prefix, strings.Repeat(" ", spaces), input[index:])
 }
 
-func (p Pulumi) RunInitCommands(repoDir string) ([]byte, error) {
-	output := make([]byte, 0)
-	proj := p.project
-
-	for _, cmd := range proj.LoadedWorkflow.InitCommands {
-		log.Info("running init command", "cmd", cmd)
-		var fields []string
-		if len(cmd.Run) > 0 {
-			fields = strings.Fields(cmd.Run)
-		} else if len(cmd.Pulumi) > 0 {
-			fields = []string{"pulumi", "--non-interactive"}
-			fields = append(fields, strings.Fields(cmd.Pulumi)...)
-		} else {
-			continue
-		}
-
-		c := exec.Command(fields[0], fields[1:]...)
-		c.Env = append(c.Environ(), cmd.GetEnv()...)
-		c.Env = append(c.Environ(), proj.LoadedWorkflow.GetEnv()...)
-		for k, v := range p.project.LoadedWorkflow.Env {
-			c.Env = append(c.Env, fmt.Sprintf("%s=%s", k, v))
-		}
-		log.Info("running command", "cmd", c, "env", c.Env)
-
-		out, err := c.CombinedOutput()
-		if err != nil {
-			log.Error("error running command", "err", err)
-		}
-		log.Info("command output", "output", string(out))
-
-		if !cmd.OmitOutput {
-			output = append(output, out...)
+func (p Pulumi) RunInitCommands(dir string) ([]byte, error) {
+	return runInitCommands(dir, p.project, func(cmd intyaml.Command) []string {
+		if len(cmd.Pulumi) == 0 {
+			return nil
 		}
-
-		if c.ProcessState.ExitCode() != 0 {
-			return output, fmt.Errorf("command %s exited with code %d", cmd.Run, c.ProcessState.ExitCode())
-		}
-	}
-
-	return output, nil
+		fields := []string{"pulumi", "--non-interactive"}
+		return append(fields, strings.Fields(cmd.Pulumi)...)
+	})
 }
@@ -0,0 +1,218 @@
+package plugin
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/log"
+
+	intyaml "github.com/ivanvc/turnip/internal/yaml"
+)
+
+const (
+	terraformDownloadURL = "https://releases.hashicorp.com/terraform/%s/terraform_%s_linux_amd64.zip"
+	tofuDownloadURL      = "https://github.com/opentofu/opentofu/releases/download/v%s/tofu_%s_linux_amd64.zip"
+	// planFile is where the plan is saved, so it can be shown as JSON.
+	planFile = "turnip.tfplan"
+)
+
+// Terraform runs Terraform, or any tool sharing its command line, such as
+// OpenTofu.
+type Terraform struct {
+	project     intyaml.Project
+	binary      string
+	downloadURL string
+	versionFile string
+	version     string
+	versionFrom string
+	skipInstall bool
+}
+
+func init() {
+	register(intyaml.TerraformAdapter{}, func(p intyaml.Project) Plugin { return NewTerraform(p) })
+	register(intyaml.TofuAdapter{}, func(p intyaml.Project) Plugin { return NewTofu(p) })
+}
+
+// NewTerraform returns the plugin for a Terraform project.
+func NewTerraform(project intyaml.Project) Terraform {
+	t := newEngine("terraform", project)
+	if a := project.LoadedWorkflow.Terraform; a != nil {
+		t.version, t.versionFrom, t.skipInstall = a.Version, a.VersionFrom, a.SkipInstall
+	}
+	return t
+}
+
+// NewTofu returns the plugin for an OpenTofu project.
+func NewTofu(project intyaml.Project) Terraform {
+	t := newEngine("tofu", project)
+	if a := project.LoadedWorkflow.Tofu; a != nil {
+		t.version, t.versionFrom, t.skipInstall = a.Version, a.VersionFrom, a.SkipInstall
+	}
+	return t
+}
+
+// newEngine returns the plugin for the given binary, either terraform or tofu.
+func newEngine(binary string, project intyaml.Project) Terraform {
+	if binary == "tofu" {
+		return Terraform{
+			project:     project,
+			binary:      "tofu",
+			downloadURL: tofuDownloadURL,
+			versionFile: ".opentofu-version",
+		}
+	}
+	return Terraform{
+		project:     project,
+		binary:      "terraform",
+		downloadURL: terraformDownloadURL,
+		versionFile: ".terraform-version",
+	}
+}
+
+func (t Terraform) InstallDependencies(dest, repoDir string) ([]byte, error) {
+	if t.skipInstall {
+		log.Info("skipping install", "binary", t.binary)
+		return []byte{}, nil
+	}
+
+	version, err := resolveVersion(filepath.Join(repoDir, t.project.Dir), t.version, t.versionFrom, t.versionFile)
+	if err != nil {
+		log.Error("error getting version", "err", err)
+		return []byte{}, err
+	}
+
+	resp, err := http.Get(fmt.Sprintf(t.downloadURL, version, version))
+	if err != nil {
+		log.Error("error downloading", "err", err)
+		return []byte{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return []byte{}, fmt.Errorf("error downloading %s %s: %s", t.binary, version, resp.Status)
+	}
+
+	filePath := path.Join(dest, t.binary+".zip")
+	out, err := os.Create(filePath)
+	if err != nil {
+		log.Error("error creating file", "err", err)
+		return []byte{}, err
+	}
+	defer out.Close()
+
+	if _, err = io.Copy(out, resp.Body); err != nil {
+		log.Error("error writing download", "err", err)
+		return []byte{}, err
+	}
+
+	output := new(bytes.Buffer)
+	cmd := exec.Command("unzip", "-o", filePath, t.binary, "-d", dest)
+	cmd.Stdout = output
+	cmd.Stderr = output
+	if err := cmd.Run(); err != nil {
+		log.Error("error executing", "err", err, "cmd", cmd)
+		return output.Bytes(), err
+	}
+
+	if err := copyFile(filepath.Join(dest, t.binary), BinDir); err != nil {
+		log.Error("error copying", "err", err)
+		return output.Bytes(), err
+	}
+
+	return output.Bytes(), nil
+}
+
+// resolveVersion returns the version to install, either the given one, or
+// read from the versionFrom file, relative to dir. If neither is set, it's read
+// from defaultFile.
+func resolveVersion(dir, version, versionFrom, defaultFile string) (string, error) {
+	if version != "" {
+		return strings.TrimPrefix(version, "v"), nil
+	}
+	if versionFrom == "" {
+		versionFrom = defaultFile
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, versionFrom))
+	if err != nil {
+		return "", err
+	}
+	version = strings.TrimPrefix(strings.TrimSpace(string(b)), "v")
+	if version == "" {
+		return "", fmt.Errorf("%s is empty", versionFrom)
+	}
+	return version, nil
+}
+
+// Plot saves the plan and returns its changes as JSON, so the server can
+// render them.
+func (t Terraform) Plot(repoDir, extraArgs string) (bool, []byte, error) {
+	if out, err := t.prepare(repoDir); err != nil {
+		return false, out, err
+	}
+
+	args := []string{"plan", "-input=false", "-no-color", "-out=" + planFile}
+	args = append(args, strings.Fields(extraArgs)...)
+	if out, err := t.run(repoDir, args...); err != nil {
+		return false, out, err
+	}
+
+	out, err := showPlan(t.binary, func(args ...string) *exec.Cmd { return t.command(repoDir, args...) })
+	return false, out, err
+}
+
+func (t Terraform) Lift(repoDir, extraArgs string) (bool, []byte, error) {
+	if out, err := t.prepare(repoDir); err != nil {
+		return false, out, err
+	}
+
+	args := []string{"apply", "-input=false", "-no-color", "-auto-approve"}
+	args = append(args, strings.Fields(extraArgs)...)
+	out, err := t.run(repoDir, args...)
+	return false, out, err
+}
+
+// prepare initializes the working directory and selects the workspace,
+// creating it if it doesn't exist.
+func (t Terraform) prepare(repoDir string) ([]byte, error) {
+	out, err := t.run(repoDir, "init", "-input=false", "-no-color")
+	if err != nil {
+		return out, err
+	}
+
+	workspace := t.project.GetWorkspace()
+	if workspace == "" {
+		return out, nil
+	}
+	wsOut, err := t.run(repoDir, "workspace", "select", "-or-create=true", workspace)
+	return append(out, wsOut...), err
+}
+
+func (t Terraform) run(repoDir string, args ...string) ([]byte, error) {
+	cmd := t.command(repoDir, args...)
+	log.Debug("running "+t.binary, "cmd", cmd)
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Error("error running "+t.binary+" "+args[0], "err", err, "output", string(out))
+		return out, err
+	}
+	return out, nil
+}
+
+func (t Terraform) command(repoDir string, args ...string) *exec.Cmd {
+	cmd := exec.Command(t.binary, args...)
+	cmd.Dir = filepath.Join(repoDir, t.project.Dir)
+	cmd.Env = append(cmd.Environ(), "TF_IN_AUTOMATION=1")
+	return cmd
+}
+
+func (t Terraform) RunInitCommands(dir string) ([]byte, error) {
+	return runInitCommands(dir, t.project, nil)
+}
@@ -0,0 +1,156 @@
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"os/exec"
+	"reflect"
+
+	"github.com/charmbracelet/log"
+)
+
+// maxPlanSize is the size after which the text plan is reported instead of
+// the JSON one, well below the RPC message size limit.
+var maxPlanSize = 4 << 20
+
+const (
+	// sensitiveMask replaces the sensitive values in the JSON plan.
+	sensitiveMask = "(sensitive value)"
+	// changedSensitiveMask replaces the sensitive values that differ from
+	// their previous value, so the server can still tell they changed.
+	changedSensitiveMask = "(sensitive value, changed)"
+)
+
+// The types below are the subset of the JSON plan format the server renders,
+// see https://developer.hashicorp.com/terraform/internals/json-format.
+// Everything else, e.g. prior_state or configuration, is dropped.
+
+type jsonPlan struct {
+	FormatVersion   string                     `json:"format_version"`
+	ResourceChanges []jsonResourceChange       `json:"resource_changes,omitempty"`
+	OutputChanges   map[string]*jsonPlanChange `json:"output_changes,omitempty"`
+}
+
+type jsonResourceChange struct {
+	Address      string         `json:"address"`
+	Mode         string         `json:"mode,omitempty"`
+	Type         string         `json:"type,omitempty"`
+	Name         string         `json:"name,omitempty"`
+	Change       jsonPlanChange `json:"change"`
+	ActionReason string         `json:"action_reason,omitempty"`
+}
+
+type jsonPlanChange struct {
+	Actions         []string `json:"actions"`
+	Before          any      `json:"before"`
+	After           any      `json:"after"`
+	AfterUnknown    any      `json:"after_unknown,omitempty"`
+	BeforeSensitive any      `json:"before_sensitive,omitempty"`
+	AfterSensitive  any      `json:"after_sensitive,omitempty"`
+	ReplacePaths    [][]any  `json:"replace_paths,omitempty"`
+}
+
+// showPlan returns the saved plan as JSON, reduced to its changes and with the
+// sensitive values masked. If it's too large, the text plan is returned
+// instead. command returns the command to run the tool with the given
+// arguments.
+func showPlan(name string, command func(args ...string) *exec.Cmd) ([]byte, error) {
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	cmd := command("show", "-json", planFile)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		log.Error("error running "+name+" show", "err", err, "stderr", stderr.String())
+		// The output may hold sensitive values, only the errors are returned.
+		return stderr.Bytes(), err
+	}
+
+	out, err := reducePlan(stdout.Bytes())
+	if err != nil {
+		log.Error("error reducing plan", "err", err)
+		return []byte{}, err
+	}
+	if len(out) <= maxPlanSize {
+		return out, nil
+	}
+
+	log.Warn("JSON plan is too large, reporting the text plan", "size", len(out))
+	out, err = command("show", "-no-color", planFile).CombinedOutput()
+	if err != nil {
+		log.Error("error running "+name+" show", "err", err, "output", string(out))
+	}
+	return out, err
+}
+
+// reducePlan returns the resource and output changes of a JSON plan, with the
+// values flagged as sensitive masked.
+func reducePlan(in []byte) ([]byte, error) {
+	d := json.NewDecoder(bytes.NewReader(in))
+	d.UseNumber()
+	p := new(jsonPlan)
+	if err := d.Decode(p); err != nil {
+		return nil, err
+	}
+
+	for i := range p.ResourceChanges {
+		p.ResourceChanges[i].Change.mask()
+	}
+	for _, c := range p.OutputChanges {
+		if c != nil {
+			c.mask()
+		}
+	}
+	return json.Marshal(p)
+}
+
+// mask replaces the sensitive values of the change. An after value that
+// differs from the before one gets a different mask, as the server would
+// otherwise render it as unchanged. The values are masked in place, so the
+// after one is masked first, while the before one can still be compared.
+func (c *jsonPlanChange) mask() {
+	c.After = maskSensitive(c.After, c.AfterSensitive, c.Before)
+	c.Before = maskSensitive(c.Before, c.BeforeSensitive, nil)
+}
+
+// maskSensitive returns the value with the parts flagged in sensitive masked.
+// The masked parts that differ from their previous value, if any, get the
+// changed mask.
+func maskSensitive(value, sensitive, previous any) any {
+	if sensitive == true {
+		switch {
+		case value == nil:
+			return nil
+		case previous != nil && !reflect.DeepEqual(value, previous):
+			return changedSensitiveMask
+		default:
+			return sensitiveMask
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		sm, _ := sensitive.(map[string]any)
+		pm, _ := previous.(map[string]any)
+		for k, val := range v {
+			v[k] = maskSensitive(val, sm[k], pm[k])
+		}
+		return v
+	case []any:
+		sl, _ := sensitive.([]any)
+		pl, _ := previous.([]any)
+		for i, val := range v {
+			v[i] = maskSensitive(val, elementAt(sl, i), elementAt(pl, i))
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func elementAt(l []any, i int) any {
+	if i < len(l) {
+		return l[i]
+	}
+	return nil
+}
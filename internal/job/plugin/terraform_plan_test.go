@@ -0,0 +1,106 @@
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testJSONPlan = `{
+  "format_version": "1.2",
+  "prior_state": {"values": {"outputs": {"password": {"sensitive": true, "value": "prior-secret"}}}},
+  "configuration": {"provider_config": {"aws": {"expressions": {"secret_key": {"constant_value": "config-secret"}}}}},
+  "resource_changes": [
+    {
+      "address": "aws_db_instance.main",
+      "mode": "managed",
+      "type": "aws_db_instance",
+      "name": "main",
+      "change": {
+        "actions": ["update"],
+        "before": {"name": "db", "password": "old-secret", "tags": {"token": "same-secret"}},
+        "after": {"name": "db", "password": "new-secret", "tags": {"token": "same-secret"}},
+        "after_unknown": {},
+        "before_sensitive": {"password": true, "tags": {"token": true}},
+        "after_sensitive": {"password": true, "tags": {"token": true}}
+      }
+    }
+  ],
+  "output_changes": {
+    "password": {
+      "actions": ["create"],
+      "before": null,
+      "after": "output-secret",
+      "after_unknown": false,
+      "before_sensitive": false,
+      "after_sensitive": true
+    }
+  }
+}`
+
+// fakeShow returns a command function that outputs the JSON plan for
+// `show -json`, and the text plan otherwise.
+func fakeShow(t *testing.T, jsonPlan, textPlan string) func(args ...string) *exec.Cmd {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "plan.json"), []byte(jsonPlan), 0640); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "plan.txt"), []byte(textPlan), 0640); err != nil {
+		t.Fatal(err)
+	}
+	return func(args ...string) *exec.Cmd {
+		if args[1] == "-json" {
+			return exec.Command("cat", filepath.Join(dir, "plan.json"))
+		}
+		return exec.Command("cat", filepath.Join(dir, "plan.txt"))
+	}
+}
+
+func TestShowPlanMasksSensitiveValues(t *testing.T) {
+	out, err := showPlan("terraform", fakeShow(t, testJSONPlan, ""))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, secret := range []string{"prior-secret", "config-secret", "old-secret", "new-secret", "same-secret", "output-secret"} {
+		if bytes.Contains(out, []byte(secret)) {
+			t.Errorf("expected %q to be masked, got %s", secret, out)
+		}
+	}
+
+	p := new(jsonPlan)
+	if err := json.Unmarshal(out, p); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c := p.ResourceChanges[0].Change
+	before, after := c.Before.(map[string]any), c.After.(map[string]any)
+	if before["name"] != "db" || after["name"] != "db" {
+		t.Errorf("expected non sensitive values to be kept, got %v and %v", before, after)
+	}
+	if before["password"] != sensitiveMask || after["password"] != changedSensitiveMask {
+		t.Errorf("expected a changed sensitive value to be flagged, got %v and %v", before["password"], after["password"])
+	}
+	if after["tags"].(map[string]any)["token"] != sensitiveMask {
+		t.Errorf("expected an unchanged sensitive value to be masked, got %v", after["tags"])
+	}
+	if p.OutputChanges["password"].After != sensitiveMask {
+		t.Errorf("expected the output to be masked, got %v", p.OutputChanges["password"].After)
+	}
+}
+
+func TestShowPlanFallsBackToTextPlan(t *testing.T) {
+	defer func(size int) { maxPlanSize = size }(maxPlanSize)
+	maxPlanSize = 10
+
+	out, err := showPlan("terraform", fakeShow(t, testJSONPlan, "Plan: 0 to add, 1 to change, 0 to destroy."))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(string(out), "Plan:") {
+		t.Errorf("expected the text plan, got %s", out)
+	}
+}
@@ -0,0 +1,44 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	intyaml "github.com/ivanvc/turnip/internal/yaml"
+)
+
+func TestResolveVersion(t *testing.T) {
+	repoDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repoDir, "infra"), 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repoDir, "infra", ".opentofu-version"), []byte("v1.6.2\n"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repoDir, "infra", "versions.txt"), []byte("1.7.0"), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	tt := []struct {
+		name     string
+		adapter  *intyaml.TofuAdapter
+		expected string
+	}{
+		{"version", &intyaml.TofuAdapter{Version: "1.6.0"}, "1.6.0"},
+		{"versionFrom", &intyaml.TofuAdapter{VersionFrom: "versions.txt"}, "1.7.0"},
+		{"default version file", &intyaml.TofuAdapter{}, "1.6.2"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			p := NewTofu(intyaml.Project{Dir: "infra", LoadedWorkflow: intyaml.Workflow{Tofu: tc.adapter}})
+			actual, err := resolveVersion(filepath.Join(repoDir, "infra"), p.version, p.versionFrom, p.versionFile)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package plugin
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/log"
+
+	intyaml "github.com/ivanvc/turnip/internal/yaml"
+)
+
+const terragruntDownloadURL = "https://github.com/gruntwork-io/terragrunt/releases/download/v%s/terragrunt_linux_amd64"
+
+// Terragrunt runs Terragrunt on a single module, or on every module under the
+// project's directory in run-all mode.
+type Terragrunt struct {
+	project intyaml.Project
+	adapter intyaml.TerragruntAdapter
+	engine  Terraform
+}
+
+func init() {
+	register(intyaml.TerragruntAdapter{}, func(p intyaml.Project) Plugin { return NewTerragrunt(p) })
+}
+
+// NewTerragrunt returns the plugin for a Terragrunt project.
+func NewTerragrunt(project intyaml.Project) Terragrunt {
+	var a intyaml.TerragruntAdapter
+	if project.LoadedWorkflow.Terragrunt != nil {
+		a = *project.LoadedWorkflow.Terragrunt
+	}
+	engine := newEngine(a.GetEngine(), project)
+	engine.version, engine.skipInstall = a.EngineVersion, a.SkipInstall
+	return Terragrunt{project: project, adapter: a, engine: engine}
+}
+
+// InstallDependencies installs both terragrunt and its engine.
+func (t Terragrunt) InstallDependencies(dest, repoDir string) ([]byte, error) {
+	if t.adapter.SkipInstall {
+		log.Info("skipping install", "binary", "terragrunt")
+		return []byte{}, nil
+	}
+
+	version, err := resolveVersion(filepath.Join(repoDir, t.project.Dir), t.adapter.Version, t.adapter.VersionFrom, ".terragrunt-version")
+	if err != nil {
+		log.Error("error getting version", "err", err)
+		return []byte{}, err
+	}
+
+	resp, err := http.Get(fmt.Sprintf(terragruntDownloadURL, version))
+	if err != nil {
+		log.Error("error downloading", "err", err)
+		return []byte{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return []byte{}, fmt.Errorf("error downloading terragrunt %s: %s", version, resp.Status)
+	}
+
+	out, err := os.OpenFile(filepath.Join(BinDir, "terragrunt"), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0755)
+	if err != nil {
+		log.Error("error creating file", "err", err)
+		return []byte{}, err
+	}
+	defer out.Close()
+
+	if _, err = io.Copy(out, resp.Body); err != nil {
+		log.Error("error writing download", "err", err)
+		return []byte{}, err
+	}
+
+	return t.engine.InstallDependencies(dest, repoDir)
+}
+
+func (t Terragrunt) Plot(repoDir, extraArgs string) (bool, []byte, error) {
+	wsOut, err := t.selectWorkspace(repoDir)
+	if err != nil {
+		return false, wsOut, err
+	}
+
+	if t.adapter.RunAll {
+		out, err := t.run(repoDir, t.args("plan", extraArgs)...)
+		return false, append(wsOut, out...), err
+	}
+
+	// A single module's plan is saved and reported as JSON, so the server can
+	// render it.
+	if out, err := t.run(repoDir, t.args("plan", "-out="+planFile+" "+extraArgs)...); err != nil {
+		return false, append(wsOut, out...), err
+	}
+
+	out, err := showPlan("terragrunt", func(args ...string) *exec.Cmd { return t.command(repoDir, args...) })
+	return false, append(wsOut, out...), err
+}
+
+func (t Terragrunt) Lift(repoDir, extraArgs string) (bool, []byte, error) {
+	wsOut, err := t.selectWorkspace(repoDir)
+	if err != nil {
+		return false, wsOut, err
+	}
+	out, err := t.run(repoDir, t.args("apply", "-auto-approve "+extraArgs)...)
+	return false, append(wsOut, out...), err
+}
+
+// selectWorkspace selects the project's workspace, creating it if it doesn't
+// exist. In run-all mode, it's selected in every module.
+func (t Terragrunt) selectWorkspace(repoDir string) ([]byte, error) {
+	args := t.workspaceArgs()
+	if args == nil {
+		return []byte{}, nil
+	}
+	return t.run(repoDir, args...)
+}
+
+// workspaceArgs returns the arguments to select the project's workspace, or
+// nil if it doesn't set one.
+func (t Terragrunt) workspaceArgs() []string {
+	workspace := t.project.GetWorkspace()
+	if workspace == "" {
+		return nil
+	}
+	args := make([]string, 0)
+	if t.adapter.RunAll {
+		args = append(args, "run-all")
+	}
+	return append(args, "workspace", "select", "-or-create=true", workspace)
+}
+
+// args returns the arguments to run the command, prefixed with run-all if
+// enabled.
+func (t Terragrunt) args(command, extraArgs string) []string {
+	args := make([]string, 0)
+	if t.adapter.RunAll {
+		args = append(args, "run-all")
+	}
+	args = append(args, command, "-input=false", "-no-color")
+	return append(args, strings.Fields(extraArgs)...)
+}
+
+func (t Terragrunt) run(repoDir string, args ...string) ([]byte, error) {
+	cmd := t.command(repoDir, args...)
+	log.Debug("running terragrunt", "cmd", cmd)
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Error("error running terragrunt", "err", err, "output", string(out))
+		return out, err
+	}
+	return out, nil
+}
+
+func (t Terragrunt) command(repoDir string, args ...string) *exec.Cmd {
+	cmd := exec.Command("terragrunt", args...)
+	cmd.Dir = filepath.Join(repoDir, t.project.Dir)
+	cmd.Env = append(
+		cmd.Environ(),
+		"TF_IN_AUTOMATION=1",
+		"TERRAGRUNT_NON_INTERACTIVE=true",
+		"TERRAGRUNT_TFPATH="+t.engine.binary,
+	)
+	return cmd
+}
+
+func (t Terragrunt) RunInitCommands(dir string) ([]byte, error) {
+	return runInitCommands(dir, t.project, nil)
+}
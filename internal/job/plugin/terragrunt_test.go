@@ -0,0 +1,33 @@
+package plugin
+
+import (
+	"reflect"
+	"testing"
+
+	intyaml "github.com/ivanvc/turnip/internal/yaml"
+)
+
+func TestTerragruntWorkspaceArgs(t *testing.T) {
+	tt := []struct {
+		name      string
+		workspace string
+		runAll    bool
+		expected  []string
+	}{
+		{"no workspace", "", false, nil},
+		{"single module", "stg", false, []string{"workspace", "select", "-or-create=true", "stg"}},
+		{"run-all", "stg", true, []string{"run-all", "workspace", "select", "-or-create=true", "stg"}},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			p := NewTerragrunt(intyaml.Project{
+				Dir:            "live/app",
+				Workspace:      tc.workspace,
+				LoadedWorkflow: intyaml.Workflow{Terragrunt: &intyaml.TerragruntAdapter{RunAll: tc.runAll}},
+			})
+			if actual := p.workspaceArgs(); !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
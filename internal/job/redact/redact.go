@@ -0,0 +1,138 @@
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Mask replaces the redacted values.
+const Mask = "[redacted]"
+
+// minValueLength is the minimum length of a value to be redacted, shorter
+// values (i.e. "1" or "true") would mask unrelated output.
+const minValueLength = 4
+
+const (
+	// pulumiSecretSig is the key Pulumi uses to tag secret values in JSON.
+	pulumiSecretSig = "4dabf18193072939515e22adb298388d"
+	// pulumiSecretValue is the value of pulumiSecretSig for secrets.
+	pulumiSecretValue = "1b47061264138c4ac30d75fd1eb44270"
+	// pulumiSecretMask is how Pulumi displays secrets.
+	pulumiSecretMask = "[secret]"
+)
+
+// Redactor masks secrets in the output of a job.
+type Redactor struct {
+	values   []string
+	patterns []*regexp.Regexp
+}
+
+// New returns a Redactor that masks the given values and the matches of the
+// given patterns. If a pattern has capturing groups, only the groups are
+// masked.
+func New(values, patterns []string) (*Redactor, error) {
+	r := new(Redactor)
+	for _, v := range values {
+		if len(v) >= minValueLength && !slices.Contains(r.values, v) {
+			r.values = append(r.values, v)
+		}
+	}
+	// Replace longer values first, so a value containing another is fully
+	// masked.
+	slices.SortFunc(r.values, func(a, b string) int { return len(b) - len(a) })
+
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// Redact returns the input with the secrets masked.
+func (r *Redactor) Redact(in []byte) []byte {
+	out := PulumiSecrets(in)
+	for _, v := range r.values {
+		out = bytes.ReplaceAll(out, []byte(v), []byte(Mask))
+	}
+	for _, re := range r.patterns {
+		out = redactPattern(re, out)
+	}
+	return out
+}
+
+// RedactString returns the input with the secrets masked.
+func (r *Redactor) RedactString(in string) string {
+	return string(r.Redact([]byte(in)))
+}
+
+func redactPattern(re *regexp.Regexp, in []byte) []byte {
+	if re.NumSubexp() == 0 {
+		return re.ReplaceAll(in, []byte(Mask))
+	}
+
+	var out bytes.Buffer
+	last := 0
+	for _, m := range re.FindAllSubmatchIndex(in, -1) {
+		for i := 2; i < len(m); i += 2 {
+			start, end := m[i], m[i+1]
+			if start < last || start < 0 {
+				continue
+			}
+			out.Write(in[last:start])
+			out.WriteString(Mask)
+			last = end
+		}
+	}
+	out.Write(in[last:])
+	return out.Bytes()
+}
+
+// PulumiSecrets masks the values Pulumi tags as secrets when the input is a
+// JSON document, otherwise it returns the input as is.
+func PulumiSecrets(in []byte) []byte {
+	if !bytes.Contains(in, []byte(pulumiSecretSig)) || !json.Valid(in) {
+		return in
+	}
+
+	d := json.NewDecoder(bytes.NewReader(in))
+	d.UseNumber()
+	var v any
+	if err := d.Decode(&v); err != nil {
+		return in
+	}
+
+	var out bytes.Buffer
+	e := json.NewEncoder(&out)
+	e.SetEscapeHTML(false)
+	e.SetIndent("", "    ")
+	if err := e.Encode(maskPulumiSecrets(v)); err != nil {
+		return in
+	}
+	return bytes.TrimSuffix(out.Bytes(), []byte("\n"))
+}
+
+func maskPulumiSecrets(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		if sig, ok := t[pulumiSecretSig].(string); ok && strings.EqualFold(sig, pulumiSecretValue) {
+			return pulumiSecretMask
+		}
+		for k, val := range t {
+			t[k] = maskPulumiSecrets(val)
+		}
+		return t
+	case []any:
+		for i, val := range t {
+			t[i] = maskPulumiSecrets(val)
+		}
+		return t
+	default:
+		return v
+	}
+}
@@ -0,0 +1,40 @@
+package plugin
+
+import "github.com/ivanvc/turnip/internal/yaml"
+
+// Custom is a plugin for the commands declared in turnip.yaml.
+type Custom struct{}
+
+func init() {
+	Register(Custom{})
+}
+
+// Name conforms to the Plugin interface.
+func (c Custom) Name() string {
+	return yaml.CustomAdapter{}.GetName()
+}
+
+// PlanName conforms to the Plugin interface.
+func (c Custom) PlanName() string {
+	return "plot"
+}
+
+// LiftName conforms to the Plugin interface.
+func (c Custom) LiftName() string {
+	return "lift"
+}
+
+// WorkspaceFlag conforms to the Plugin interface.
+func (c Custom) WorkspaceFlag() Flag {
+	return Flag{Name: "workspace", Shorthand: "w", Usage: "the workspace to use"}
+}
+
+// AutoPlan conforms to the Plugin interface.
+func (c Custom) AutoPlan(project *yaml.Project) bool {
+	return project.AutoPlan
+}
+
+// FormatDiff conforms to the Plugin interface.
+func (c Custom) FormatDiff(diff []byte) (string, error) {
+	return formatTextDiff(diff), nil
+}
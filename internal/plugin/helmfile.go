@@ -0,0 +1,49 @@
+package plugin
+
+import (
+	"fmt"
+
+	"github.com/ivanvc/turnip/internal/yaml"
+)
+
+// Helmfile is a plugin.
+type Helmfile struct{}
+
+func init() {
+	Register(Helmfile{})
+}
+
+// Name conforms to the Plugin interface.
+func (h Helmfile) Name() string {
+	return yaml.HelmfileAdapter{}.GetName()
+}
+
+// PlanName conforms to the Plugin interface.
+func (h Helmfile) PlanName() string {
+	return "diff"
+}
+
+// LiftName conforms to the Plugin interface.
+func (h Helmfile) LiftName() string {
+	return "apply"
+}
+
+// WorkspaceFlag conforms to the Plugin interface.
+func (h Helmfile) WorkspaceFlag() Flag {
+	return Flag{Name: "environment", Shorthand: "e", Usage: "the Helmfile environment to use"}
+}
+
+// AutoPlan conforms to the Plugin interface.
+func (h Helmfile) AutoPlan(project *yaml.Project) bool {
+	return project.AutoDiff
+}
+
+// FormatDiff conforms to the Plugin interface.
+func (h Helmfile) FormatDiff(diff []byte) (string, error) {
+	return formatTextDiff(diff), nil
+}
+
+// formatTextDiff renders a plain text diff in a code block.
+func formatTextDiff(diff []byte) string {
+	return fmt.Sprintf("```diff\n%s\n```", diff)
+}
@@ -0,0 +1,42 @@
+package plugin
+
+import (
+	"github.com/ivanvc/turnip/internal/yaml"
+)
+
+// Kustomize is a plugin.
+type Kustomize struct{}
+
+func init() {
+	Register(Kustomize{})
+}
+
+// Name conforms to the Plugin interface.
+func (k Kustomize) Name() string {
+	return yaml.KustomizeAdapter{}.GetName()
+}
+
+// PlanName conforms to the Plugin interface.
+func (k Kustomize) PlanName() string {
+	return "diff"
+}
+
+// LiftName conforms to the Plugin interface.
+func (k Kustomize) LiftName() string {
+	return "apply"
+}
+
+// WorkspaceFlag conforms to the Plugin interface.
+func (k Kustomize) WorkspaceFlag() Flag {
+	return Flag{Name: "context", Shorthand: "c", Usage: "the kube context to use"}
+}
+
+// AutoPlan conforms to the Plugin interface.
+func (k Kustomize) AutoPlan(project *yaml.Project) bool {
+	return project.AutoDiff
+}
+
+// FormatDiff conforms to the Plugin interface.
+func (k Kustomize) FormatDiff(diff []byte) (string, error) {
+	return formatTextDiff(diff), nil
+}
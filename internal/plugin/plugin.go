@@ -1,6 +1,13 @@
 package plugin
 
-import "github.com/ivanvc/turnip/internal/yaml"
+import (
+	"fmt"
+	"sort"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/ivanvc/turnip/internal/yaml"
+)
 
 // Plugin is the interface that must be implemented by all plugins.
 type Plugin interface {
@@ -10,10 +17,173 @@ type Plugin interface {
 	PlanName() string
 	// LiftName returns the name of the lift command.
 	LiftName() string
-	// Workspace returns the workspace/sack/environment to use for the project.
-	Workspace(project *yaml.Project) string
+	// WorkspaceFlag returns the command flag used to select the workspace.
+	WorkspaceFlag() Flag
 	// AutoPlan returns the auto plan configuration.
 	AutoPlan(project *yaml.Project) bool
 	// FormatDiff returns the formatted diff.
 	FormatDiff(diff []byte) (string, error)
 }
+
+// Source gives access to the files of a repository, as discovery.Source.
+type Source interface {
+	ListFiles() ([]string, error)
+	FetchFile(path string) ([]byte, error)
+}
+
+// Files gives access to the files of the repository, by their path from its
+// root. They're listed once, and only the ones that exist are fetched.
+type Files struct {
+	src   Source
+	paths map[string]bool
+}
+
+// NewFiles returns the files of the source.
+func NewFiles(src Source) *Files {
+	return &Files{src: src}
+}
+
+// Has returns whether the file exists.
+func (f *Files) Has(path string) bool {
+	if f.paths == nil {
+		files, err := f.src.ListFiles()
+		if err != nil {
+			log.Error("error listing files", "error", err)
+			return false
+		}
+		f.paths = make(map[string]bool, len(files))
+		for _, file := range files {
+			f.paths[file] = true
+		}
+	}
+	return f.paths[path]
+}
+
+// Fetch returns the contents of the file.
+func (f *Files) Fetch(path string) ([]byte, error) {
+	if !f.Has(path) {
+		return nil, fmt.Errorf("%s not found", path)
+	}
+	return f.src.FetchFile(path)
+}
+
+// Discoverer is implemented by the plugins whose projects can be discovered
+// from the repository's layout, see yaml.AutodiscoverRule.
+type Discoverer interface {
+	// Discover returns the stacks of the projects in the directory, given the
+	// sorted names of its files. A project without a stack is returned as an
+	// empty one.
+	Discover(dir string, names []string, files *Files) []string
+}
+
+// WhenModifiedDefaulter is implemented by the plugins that derive the default
+// whenModified rules of a project from its files. The rules are relative to
+// the project's directory.
+type WhenModifiedDefaulter interface {
+	DefaultWhenModified(project *yaml.Project, files *Files) []string
+}
+
+// WhenModified returns the whenModified rules of the project, or the default
+// ones if it doesn't set any.
+func WhenModified(project *yaml.Project, files *Files) []string {
+	if len(project.WhenModified) > 0 {
+		return project.WhenModified
+	}
+	if p, err := ForProject(project); err == nil {
+		if d, ok := p.(WhenModifiedDefaulter); ok {
+			return d.DefaultWhenModified(project, files)
+		}
+	}
+	return []string{"./**/*"}
+}
+
+// Flag describes a command flag.
+type Flag struct {
+	Name      string
+	Shorthand string
+	Usage     string
+}
+
+var registry = make(map[string]Plugin)
+
+// Register adds the plugin to the registry, keyed by its name, which must
+// match the name of the adapter in turnip.yaml.
+func Register(p Plugin) {
+	if _, ok := registry[p.Name()]; ok {
+		panic(fmt.Sprintf("plugin %s already registered", p.Name()))
+	}
+	registry[p.Name()] = p
+}
+
+// Get returns the plugin registered with the given name.
+func Get(name string) (Plugin, bool) {
+	p, ok := registry[name]
+	return p, ok
+}
+
+// All returns the registered plugins sorted by name.
+func All() []Plugin {
+	plugins := make([]Plugin, 0, len(registry))
+	for _, p := range registry {
+		plugins = append(plugins, p)
+	}
+	sort.Slice(plugins, func(i, j int) bool {
+		return plugins[i].Name() < plugins[j].Name()
+	})
+	return plugins
+}
+
+// ForProject returns the plugin for the project's adapter.
+func ForProject(project *yaml.Project) (Plugin, error) {
+	name := project.GetAdapterName()
+	p, ok := Get(name)
+	if !ok {
+		return nil, fmt.Errorf("no plugin registered for adapter %q", name)
+	}
+	return p, nil
+}
+
+// Validate checks that there's a plugin registered for the adapter of every
+// project in the configuration.
+func Validate(cfg yaml.Config) error {
+	for _, prj := range cfg.Projects {
+		if _, err := ForProject(&prj); err != nil {
+			return fmt.Errorf("project %s: %s", prj.Dir, err.Error())
+		}
+	}
+	return nil
+}
+
+// CheckName returns the name of the status check for running the command
+// (plot or lift) on the project. Named projects are identified by their name,
+// the rest by their dir and workspace.
+func CheckName(project *yaml.Project, cmdName string) (string, error) {
+	p, err := ForProject(project)
+	if err != nil {
+		return "", err
+	}
+
+	cmd := p.PlanName()
+	if cmdName == "lift" {
+		cmd = p.LiftName()
+	}
+	if project.Name != "" {
+		return fmt.Sprintf("turnip/%s/%s/%s", p.Name(), cmd, project.Name), nil
+	}
+	return fmt.Sprintf("turnip/%s/%s/%s/%s", p.Name(), cmd, project.Dir, project.GetWorkspace()), nil
+}
+
+// FormatOutput formats the output of a job using the plugin registered for
+// the adapter. It returns false if there's no such plugin, or it fails to
+// format the output.
+func FormatOutput(adapter string, output []byte) (string, bool) {
+	p, ok := Get(adapter)
+	if !ok {
+		return "", false
+	}
+	out, err := p.FormatDiff(output)
+	if err != nil {
+		return "", false
+	}
+	return out, true
+}
@@ -0,0 +1,158 @@
+package plugin
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/ivanvc/turnip/internal/yaml"
+)
+
+func TestCheckName(t *testing.T) {
+	tt := []struct {
+		project  yaml.Project
+		cmdName  string
+		expected string
+	}{
+		{
+			yaml.Project{Dir: "infra", Workspace: "prod", LoadedWorkflow: yaml.Workflow{Terraform: &yaml.TerraformAdapter{}}},
+			"plot",
+			"turnip/terraform/plan/infra/prod",
+		},
+		{
+			yaml.Project{Dir: "app", Stack: "dev", LoadedWorkflow: yaml.Workflow{Pulumi: &yaml.PulumiAdapter{}}},
+			"lift",
+			"turnip/pulumi/up/app/dev",
+		},
+		{
+			yaml.Project{Dir: "charts", Environment: "stg", LoadedWorkflow: yaml.Workflow{Helmfile: &yaml.HelmfileAdapter{}}},
+			"plot",
+			"turnip/helmfile/diff/charts/stg",
+		},
+		{
+			yaml.Project{Dir: "infra", LoadedWorkflow: yaml.Workflow{Tofu: &yaml.TofuAdapter{Workspace: "default"}}},
+			"lift",
+			"turnip/tofu/apply/infra/default",
+		},
+		{
+			yaml.Project{Name: "network", Dir: "infra/network", Workspace: "prod", LoadedWorkflow: yaml.Workflow{Terraform: &yaml.TerraformAdapter{}}},
+			"plot",
+			"turnip/terraform/plan/network",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.expected, func(t *testing.T) {
+			actual, err := CheckName(&tc.project, tc.cmdName)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cfg := yaml.Config{Projects: []yaml.Project{{Dir: "infra"}}}
+	if err := Validate(cfg); err == nil {
+		t.Error("expected an error for a project without an adapter")
+	}
+
+	cfg.Projects[0].LoadedWorkflow = yaml.Workflow{Terraform: &yaml.TerraformAdapter{}}
+	if err := Validate(cfg); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestDiscoverers(t *testing.T) {
+	for _, typ := range yaml.AutodiscoverTypes {
+		p, ok := Get(typ)
+		if _, isDiscoverer := p.(Discoverer); !ok || !isDiscoverer {
+			t.Errorf("expected a plugin discovering %s projects", typ)
+		}
+	}
+}
+
+func TestWhenModified(t *testing.T) {
+	files := map[string]string{
+		"live/prod/app/terragrunt.hcl": `include "root" {
+  path = find_in_parent_folders("root.hcl")
+}
+
+dependency "vpc" {
+  config_path = "../vpc"
+}
+`,
+		"live/root.hcl": `remote_state {}`,
+	}
+	src := &fakeSource{files: files}
+
+	tt := []struct {
+		name     string
+		project  yaml.Project
+		expected []string
+	}{
+		{
+			"terragrunt",
+			yaml.Project{Dir: "live/prod/app", LoadedWorkflow: yaml.Workflow{Terragrunt: &yaml.TerragruntAdapter{}}},
+			[]string{"./**/*", "../../root.hcl", "../vpc/**/*"},
+		},
+		{
+			"explicit rules",
+			yaml.Project{Dir: "live/prod/app", WhenModified: []string{"*.hcl"}, LoadedWorkflow: yaml.Workflow{Terragrunt: &yaml.TerragruntAdapter{}}},
+			[]string{"*.hcl"},
+		},
+		{
+			"terraform",
+			yaml.Project{Dir: "infra", LoadedWorkflow: yaml.Workflow{Terraform: &yaml.TerraformAdapter{}}},
+			[]string{"./**/*"},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := WhenModified(&tc.project, NewFiles(src))
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+	if expected := []string{"live/prod/app/terragrunt.hcl"}; !reflect.DeepEqual(src.fetched, expected) {
+		t.Errorf("expected only %v to be fetched, got %v", expected, src.fetched)
+	}
+}
+
+// fakeSource serves the files, recording the ones fetched.
+type fakeSource struct {
+	files   map[string]string
+	fetched []string
+}
+
+func (s *fakeSource) ListFiles() ([]string, error) {
+	files := make([]string, 0, len(s.files))
+	for f := range s.files {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func (s *fakeSource) FetchFile(path string) ([]byte, error) {
+	s.fetched = append(s.fetched, path)
+	data, ok := s.files[path]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return []byte(data), nil
+}
+
+func TestFormatOutput(t *testing.T) {
+	out, ok := FormatOutput("custom", []byte("+ added"))
+	if !ok {
+		t.Fatal("expected the custom plugin to format the output")
+	}
+	if expected := "```diff\n+ added\n```"; out != expected {
+		t.Errorf("expected %q, got %q", expected, out)
+	}
+}
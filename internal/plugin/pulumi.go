@@ -1,16 +1,25 @@
 package plugin
 
 import (
+	"regexp"
+	"slices"
+
 	"github.com/ivanvc/turnip/internal/plugin/pulumi"
 	"github.com/ivanvc/turnip/internal/yaml"
 )
 
+var pulumiStackRegexp = regexp.MustCompile(`^Pulumi\.(.+)\.ya?ml$`)
+
 // Pulumi is a plugin.
 type Pulumi struct{}
 
+func init() {
+	Register(Pulumi{})
+}
+
 // Name conforms to the Plugin interface.
 func (p Pulumi) Name() string {
-	return "pulumi"
+	return yaml.PulumiAdapter{}.GetName()
 }
 
 // PlanName conforms to the Plugin interface.
@@ -23,9 +32,9 @@ func (p Pulumi) LiftName() string {
 	return "up"
 }
 
-// Workspace conforms to the Plugin interface.
-func (p Pulumi) Workspace(project *yaml.Project) string {
-	return project.Stack
+// WorkspaceFlag conforms to the Plugin interface.
+func (p Pulumi) WorkspaceFlag() Flag {
+	return Flag{Name: "stack", Shorthand: "s", Usage: "the Pulumi stack to use"}
 }
 
 // AutoPlan conforms to the Plugin interface.
@@ -38,3 +47,18 @@ func (p Pulumi) FormatDiff(diff []byte) (string, error) {
 	f := pulumi.NewFormatter(diff)
 	return f.Format()
 }
+
+// Discover conforms to the Discoverer interface. A project is a directory
+// with a Pulumi.yaml, with a stack for every Pulumi.<stack>.yaml next to it.
+func (p Pulumi) Discover(dir string, names []string, files *Files) []string {
+	if !slices.Contains(names, "Pulumi.yaml") && !slices.Contains(names, "Pulumi.yml") {
+		return nil
+	}
+	stacks := make([]string, 0)
+	for _, name := range names {
+		if m := pulumiStackRegexp.FindStringSubmatch(name); m != nil {
+			stacks = append(stacks, m[1])
+		}
+	}
+	return stacks
+}
@@ -3,45 +3,93 @@ package pulumi
 import (
 	"encoding/json"
 	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/log"
 )
 
-// TODO: Use https://github.com/pulumi/pulumi/blob/e13780c0bd60fa5f8fda011e8221b1b956b97738/pkg/display/json.go
+// The types below follow the preview digest emitted by `pulumi preview --json`,
+// see https://github.com/pulumi/pulumi/blob/master/pkg/display/json.go
 
 type diff struct {
-	Steps         []step        `json:"steps"`
-	Duration      time.Duration `json:"duration"`
-	ChangeSummary changeSummary `json:"changeSummary"`
+	Steps         []step         `json:"steps"`
+	Diagnostics   []diagnostic   `json:"diagnostics"`
+	Duration      time.Duration  `json:"duration"`
+	ChangeSummary map[Op]int     `json:"changeSummary"`
+	Config        map[string]any `json:"config"`
 }
 
 type step struct {
-	Op       `json:"op"`
-	URN      string   `json:"urn"`
-	NewState newState `json:"newState"`
+	Op             `json:"op"`
+	URN            string                  `json:"urn"`
+	OldState       *state                  `json:"oldState"`
+	NewState       *state                  `json:"newState"`
+	DiffReasons    []string                `json:"diffReasons"`
+	ReplaceReasons []string                `json:"replaceReasons"`
+	DetailedDiff   map[string]propertyDiff `json:"detailedDiff"`
 }
 
+type state struct {
+	URN       string         `json:"urn"`
+	StateType string         `json:"type"`
+	Inputs    map[string]any `json:"inputs"`
+}
+
+type propertyDiff struct {
+	Kind      string `json:"diffKind"`
+	InputDiff bool   `json:"inputDiff"`
+}
+
+type diagnostic struct {
+	URN      string `json:"urn"`
+	Message  string `json:"message"`
+	Severity string `json:"severity"`
+}
+
+// Op is the operation performed on a resource.
 type Op string
 
 const (
-	opCreate Op = "create"
-	opDelete Op = "delete"
-	opModify Op = "modify"
+	opSame              Op = "same"
+	opCreate            Op = "create"
+	opUpdate            Op = "update"
+	opDelete            Op = "delete"
+	opReplace           Op = "replace"
+	opCreateReplacement Op = "create-replacement"
+	opDeleteReplaced    Op = "delete-replaced"
+	opRead              Op = "read"
+	opRefresh           Op = "refresh"
+	opImport            Op = "import"
 )
 
-type newState struct {
-	URN       string         `json:"urn"`
-	StateType string         `json:"type"`
-	Inputs    map[string]any `json:"inputs"`
-}
+const (
+	// secretSig is the key Pulumi uses to tag secret values.
+	secretSig = "4dabf18193072939515e22adb298388d"
+	// secretMask is how Pulumi displays secrets.
+	secretMask = "[secret]"
+)
 
-type changeSummary struct {
-	Create int `json:"create"`
-	Delete int `json:"delete"`
+// summaryOps is the order in which operations are listed in the summary.
+var summaryOps = []struct {
+	op    Op
+	label string
+}{
+	{opCreate, "to create"},
+	{opUpdate, "to update"},
+	{opReplace, "to replace"},
+	{opDelete, "to delete"},
+	{opImport, "to import"},
+	{opRead, "to read"},
+	{opRefresh, "to refresh"},
+	{opSame, "unchanged"},
 }
 
+var pathSegmentRegexp = regexp.MustCompile(`\[(\d+)\]|\["((?:[^"\\]|\\.)*)"\]|([^.\[\]]+)`)
+
 type Formatter struct {
 	input []byte
 }
@@ -50,72 +98,270 @@ func NewFormatter(input []byte) *Formatter {
 	return &Formatter{input}
 }
 
+// Format renders the preview as Markdown. If the input is not a JSON preview,
+// it's returned in a diff code block.
 func (f *Formatter) Format() (string, error) {
 	d := new(diff)
 	err := json.Unmarshal(f.input, &d)
 	if err != nil {
-		log.Error("error parsing diff", "err", err, "input", string(f.input))
-		return fmt.Sprintf("```\n%s\n```", string(f.input)), nil
+		log.Debug("output is not a JSON diff, rendering as is", "err", err)
+		return fmt.Sprintf("```diff\n%s\n```", string(f.input)), nil
 	}
 	log.Debug("parsed diff", "diff", d)
 
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("Changes: %d created, %d deleted\n", d.ChangeSummary.Create, d.ChangeSummary.Delete))
-	sb.WriteString(fmt.Sprintf("Duration: %s\n\n```diff\n", d.Duration))
+	sb.WriteString(fmt.Sprintf("Changes: %s\n", formatSummary(d.ChangeSummary)))
+	sb.WriteString(fmt.Sprintf("Duration: %s\n", d.Duration))
 
-	if len(d.Steps) == 0 {
-		sb.WriteString("No changes")
+	if diags := formatDiagnostics(d.Diagnostics); diags != "" {
+		sb.WriteString("\n" + diags)
 	}
+
+	sb.WriteString("\n```diff\n")
+	changes := 0
 	for _, s := range d.Steps {
-		switch s.Op {
-		case "same":
+		out := formatStep(s)
+		if out == "" {
 			continue
-		case "create":
-			sb.WriteString("+ ")
-		case "delete":
-			sb.WriteString("- ")
-		case "modify":
-			sb.WriteString("~ ")
-		}
-		sb.WriteString(fmt.Sprintf("%s:\n", s.NewState.StateType))
-		sb.WriteString(fmt.Sprintf("\t[urn=%s]\n", s.NewState.URN))
-		for k, v := range s.NewState.Inputs {
-			if strings.HasPrefix(k, "__") {
-				continue
-			}
-			sb.WriteString(fmt.Sprintf("\t%s: %s\n", k, formatInputValue(v, "\t")))
 		}
+		changes++
+		sb.WriteString(out)
 		sb.WriteString("\n")
 	}
+	if changes == 0 {
+		sb.WriteString("No changes\n")
+	}
 	sb.WriteString("```")
 
 	return sb.String(), nil
 }
 
-func formatInputValue(value any, tabs string) string {
-	switch value.(type) {
+func formatSummary(summary map[Op]int) string {
+	parts := make([]string, 0)
+	for _, s := range summaryOps {
+		if n := summary[s.op]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, s.label))
+		}
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatDiagnostics(diags []diagnostic) string {
+	var sb strings.Builder
+	for _, d := range diags {
+		if d.Severity != "error" && d.Severity != "warning" {
+			continue
+		}
+		msg := strings.TrimSpace(d.Message)
+		if d.URN != "" {
+			msg = fmt.Sprintf("%s: %s", resourceName(d.URN), msg)
+		}
+		sb.WriteString(fmt.Sprintf("* **%s**: %s\n", d.Severity, strings.ReplaceAll(msg, "\n", " ")))
+	}
+	return sb.String()
+}
+
+// formatStep renders a single step, returning an empty string for the steps
+// that don't change anything, or that are already covered by a replace.
+func formatStep(s step) string {
+	var sb strings.Builder
+	switch s.Op {
+	case opCreate, opImport:
+		sb.WriteString(formatResource("+ ", s, s.NewState))
+		sb.WriteString(formatInputs("+ ", inputs(s.NewState)))
+	case opDelete:
+		sb.WriteString(formatResource("- ", s, s.OldState))
+		sb.WriteString(formatInputs("- ", inputs(s.OldState)))
+	case opUpdate:
+		sb.WriteString(formatResource("~ ", s, s.NewState))
+		sb.WriteString(formatPropertyDiffs(s))
+	case opReplace:
+		sb.WriteString(formatResource("+-", s, s.NewState))
+		if len(s.ReplaceReasons) > 0 {
+			sb.WriteString(fmt.Sprintf("!     replaced because of changes to: %s\n", strings.Join(s.ReplaceReasons, ", ")))
+		}
+		sb.WriteString(formatPropertyDiffs(s))
+	case opCreateReplacement, opDeleteReplaced:
+		// Already covered by the replace step.
+		return ""
+	default:
+		return ""
+	}
+	return sb.String()
+}
+
+func formatResource(prefix string, s step, st *state) string {
+	typ := ""
+	if st != nil {
+		typ = st.StateType
+	}
+	if typ == "" {
+		typ = resourceType(s.URN)
+	}
+	return fmt.Sprintf("%s %s: (%s)\n", prefix, typ, resourceName(s.URN))
+}
+
+func formatInputs(prefix string, in map[string]any) string {
+	var sb strings.Builder
+	for _, k := range sortedKeys(in) {
+		if strings.HasPrefix(k, "__") {
+			continue
+		}
+		sb.WriteString(prefixLines(prefix, fmt.Sprintf("    %s: %s", k, formatValue(in[k], "    "))))
+	}
+	return sb.String()
+}
+
+// formatPropertyDiffs renders the old and new values of the changed
+// properties of an update or replace.
+func formatPropertyDiffs(s step) string {
+	paths := make([]string, 0, len(s.DetailedDiff))
+	for p := range s.DetailedDiff {
+		paths = append(paths, p)
+	}
+	if len(paths) == 0 {
+		paths = append(paths, s.DiffReasons...)
+	}
+	sort.Strings(paths)
+
+	oldInputs, newInputs := inputs(s.OldState), inputs(s.NewState)
+	var sb strings.Builder
+	for _, p := range paths {
+		kind := s.DetailedDiff[p].Kind
+		oldValue, hasOld := lookup(oldInputs, p)
+		newValue, hasNew := lookup(newInputs, p)
+		switch {
+		case strings.HasPrefix(kind, "add") || (!hasOld && hasNew):
+			sb.WriteString(prefixLines("+ ", fmt.Sprintf("    %s: %s", p, formatValue(newValue, "    "))))
+		case strings.HasPrefix(kind, "delete") || (hasOld && !hasNew):
+			sb.WriteString(prefixLines("- ", fmt.Sprintf("    %s: %s", p, formatValue(oldValue, "    "))))
+		default:
+			sb.WriteString(prefixLines("~ ", fmt.Sprintf(
+				"    %s: %s => %s",
+				p,
+				formatValue(oldValue, "    "),
+				formatValue(newValue, "    "),
+			)))
+		}
+	}
+	return sb.String()
+}
+
+func inputs(st *state) map[string]any {
+	if st == nil {
+		return nil
+	}
+	return st.Inputs
+}
+
+// lookup resolves a property path such as `tags.env` or `ingress[0].cidr`.
+func lookup(in map[string]any, path string) (any, bool) {
+	var cur any = in
+	for _, m := range pathSegmentRegexp.FindAllStringSubmatch(path, -1) {
+		switch v := cur.(type) {
+		case map[string]any:
+			key := m[3]
+			if m[2] != "" {
+				key = m[2]
+			} else if m[1] != "" {
+				key = m[1]
+			}
+			next, ok := v[key]
+			if !ok {
+				return nil, false
+			}
+			cur = next
+		case []any:
+			i, err := strconv.Atoi(m[1])
+			if err != nil || i >= len(v) {
+				return nil, false
+			}
+			cur = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+func formatValue(value any, indent string) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
 	case string:
+		if strings.Contains(v, "\n") {
+			return "|\n" + indent + "    " + strings.ReplaceAll(strings.TrimSuffix(v, "\n"), "\n", "\n"+indent+"    ")
+		}
+		return strconv.Quote(v)
+	case []any:
+		if len(v) == 0 {
+			return "[]"
+		}
 		var sb strings.Builder
-		for i, l := range strings.Split(value.(string), "\n") {
-			if i == 0 {
-				sb.WriteString(l)
-			} else {
-				sb.WriteString(fmt.Sprintf("\n%s%s", tabs, l))
-			}
+		sb.WriteString("[")
+		for _, item := range v {
+			sb.WriteString(fmt.Sprintf("\n%s    %s", indent, formatValue(item, indent+"    ")))
 		}
+		sb.WriteString(fmt.Sprintf("\n%s]", indent))
 		return sb.String()
-	case []any:
+	case map[string]any:
+		if _, ok := v[secretSig]; ok {
+			return secretMask
+		}
+		if len(v) == 0 {
+			return "{}"
+		}
 		var sb strings.Builder
-		sb.WriteString("[\n")
-		for i, v := range value.([]any) {
-			sb.WriteString(fmt.Sprintf("\t%s%s", tabs, formatInputValue(v, tabs+"\t")))
-			if i < len(value.([]any))-1 {
-				sb.WriteString(",\n")
-			}
+		sb.WriteString("{")
+		for _, k := range sortedKeys(v) {
+			sb.WriteString(fmt.Sprintf("\n%s    %s: %s", indent, k, formatValue(v[k], indent+"    ")))
 		}
-		sb.WriteString(fmt.Sprintf("\n%s]", tabs))
+		sb.WriteString(fmt.Sprintf("\n%s}", indent))
 		return sb.String()
 	default:
-		return fmt.Sprintf("%v", value)
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// prefixLines adds the diff prefix to every line, so multiline values are
+// highlighted too.
+func prefixLines(prefix, s string) string {
+	var sb strings.Builder
+	for _, l := range strings.Split(s, "\n") {
+		sb.WriteString(prefix + l + "\n")
+	}
+	return sb.String()
+}
+
+// resourceName returns the name of the resource from its URN.
+func resourceName(urn string) string {
+	if i := strings.LastIndex(urn, "::"); i >= 0 {
+		return urn[i+2:]
+	}
+	return urn
+}
+
+// resourceType returns the type of the resource from its URN.
+func resourceType(urn string) string {
+	parts := strings.Split(urn, "::")
+	if len(parts) < 2 {
+		return ""
+	}
+	typ := parts[len(parts)-2]
+	if i := strings.LastIndex(typ, "$"); i >= 0 {
+		typ = typ[i+1:]
+	}
+	return typ
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
 	}
+	sort.Strings(keys)
+	return keys
 }
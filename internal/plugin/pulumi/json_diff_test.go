@@ -0,0 +1,101 @@
+package pulumi
+
+import (
+	"strings"
+	"testing"
+)
+
+const preview = `{
+  "steps": [
+    {
+      "op": "create",
+      "urn": "urn:pulumi:dev::infra::aws:s3/bucket:Bucket::logs",
+      "newState": {"type": "aws:s3/bucket:Bucket", "inputs": {"acl": "private", "__defaults": []}}
+    },
+    {
+      "op": "update",
+      "urn": "urn:pulumi:dev::infra::aws:ec2/instance:Instance::web",
+      "oldState": {"type": "aws:ec2/instance:Instance", "inputs": {"instanceType": "t2.micro", "tags": {"env": "dev"}}},
+      "newState": {"type": "aws:ec2/instance:Instance", "inputs": {"instanceType": "t3.micro", "tags": {"env": "dev", "team": "infra"}}},
+      "diffReasons": ["instanceType", "tags"],
+      "detailedDiff": {"instanceType": {"diffKind": "update"}, "tags.team": {"diffKind": "add"}}
+    },
+    {
+      "op": "replace",
+      "urn": "urn:pulumi:dev::infra::aws:rds/instance:Instance::db",
+      "oldState": {"type": "aws:rds/instance:Instance", "inputs": {"engineVersion": "13", "password": {"4dabf18193072939515e22adb298388d": "1b47061264138c4ac30d75fd1eb44270", "plaintext": "\"x\""}}},
+      "newState": {"type": "aws:rds/instance:Instance", "inputs": {"engineVersion": "15"}},
+      "replaceReasons": ["engineVersion"],
+      "detailedDiff": {"engineVersion": {"diffKind": "update-replace"}}
+    },
+    {"op": "create-replacement", "urn": "urn:pulumi:dev::infra::aws:rds/instance:Instance::db"},
+    {"op": "delete-replaced", "urn": "urn:pulumi:dev::infra::aws:rds/instance:Instance::db"},
+    {
+      "op": "delete",
+      "urn": "urn:pulumi:dev::infra::aws:sqs/queue:Queue::jobs",
+      "oldState": {"type": "aws:sqs/queue:Queue", "inputs": {"name": "jobs"}}
+    },
+    {"op": "same", "urn": "urn:pulumi:dev::infra::pulumi:pulumi:Stack::infra-dev"}
+  ],
+  "duration": 2000000000,
+  "changeSummary": {"create": 1, "update": 1, "replace": 1, "delete": 1, "same": 1}
+}`
+
+func TestFormat(t *testing.T) {
+	out, err := NewFormatter([]byte(preview)).Format()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"Changes: 1 to create, 1 to update, 1 to replace, 1 to delete, 1 unchanged\n",
+		"Duration: 2s\n",
+		"+  aws:s3/bucket:Bucket: (logs)\n+     acl: \"private\"\n",
+		"~  aws:ec2/instance:Instance: (web)\n~     instanceType: \"t2.micro\" => \"t3.micro\"\n+     tags.team: \"infra\"\n",
+		"+- aws:rds/instance:Instance: (db)\n!     replaced because of changes to: engineVersion\n~     engineVersion: \"13\" => \"15\"\n",
+		"-  aws:sqs/queue:Queue: (jobs)\n-     name: \"jobs\"\n",
+	}
+	for _, e := range expected {
+		if !strings.Contains(out, e) {
+			t.Errorf("expected output to contain %q, got:\n%s", e, out)
+		}
+	}
+	if strings.Contains(out, "__defaults") {
+		t.Errorf("expected internal inputs to be omitted, got:\n%s", out)
+	}
+	if strings.Count(out, "(db)") != 1 {
+		t.Errorf("expected replacement steps to be folded into the replace, got:\n%s", out)
+	}
+}
+
+func TestFormatNotJSON(t *testing.T) {
+	out, _ := NewFormatter([]byte("error: no stack named 'dev' found")).Format()
+	if out != "```diff\nerror: no stack named 'dev' found\n```" {
+		t.Errorf("expected raw output in a code block, got %q", out)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	in := map[string]any{
+		"tags":    map[string]any{"a.b": "c"},
+		"ingress": []any{map[string]any{"cidr": "10.0.0.0/8"}},
+	}
+	tt := []struct {
+		path     string
+		expected any
+		ok       bool
+	}{
+		{`tags["a.b"]`, "c", true},
+		{"ingress[0].cidr", "10.0.0.0/8", true},
+		{"ingress[1].cidr", nil, false},
+		{"missing", nil, false},
+	}
+	for _, tc := range tt {
+		t.Run(tc.path, func(t *testing.T) {
+			v, ok := lookup(in, tc.path)
+			if ok != tc.ok || v != tc.expected {
+				t.Errorf("expected (%v, %v), got (%v, %v)", tc.expected, tc.ok, v, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package plugin
+
+import (
+	"path"
+	"regexp"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/ivanvc/turnip/internal/plugin/terraform"
+	"github.com/ivanvc/turnip/internal/yaml"
+)
+
+var backendRegexp = regexp.MustCompile(`(?m)^\s*(backend\s+"[^"]+"|cloud)\s*\{`)
+
+// Terraform is a plugin.
+type Terraform struct{}
+
+func init() {
+	Register(Terraform{})
+}
+
+// Name conforms to the Plugin interface.
+func (t Terraform) Name() string {
+	return yaml.TerraformAdapter{}.GetName()
+}
+
+// PlanName conforms to the Plugin interface.
+func (t Terraform) PlanName() string {
+	return "plan"
+}
+
+// LiftName conforms to the Plugin interface.
+func (t Terraform) LiftName() string {
+	return "apply"
+}
+
+// WorkspaceFlag conforms to the Plugin interface.
+func (t Terraform) WorkspaceFlag() Flag {
+	return Flag{Name: "workspace", Shorthand: "w", Usage: "the workspace to use"}
+}
+
+// AutoPlan conforms to the Plugin interface.
+func (t Terraform) AutoPlan(project *yaml.Project) bool {
+	return project.AutoPlan
+}
+
+// FormatDiff conforms to the Plugin interface.
+func (t Terraform) FormatDiff(diff []byte) (string, error) {
+	f := terraform.NewFormatter(diff)
+	return f.Format()
+}
+
+// Discover conforms to the Discoverer interface. A project is a directory
+// with .tf files declaring a backend, which are fetched until one is found.
+func (t Terraform) Discover(dir string, names []string, files *Files) []string {
+	for _, name := range names {
+		if path.Ext(name) != ".tf" {
+			continue
+		}
+		data, err := files.Fetch(path.Join(dir, name))
+		if err != nil {
+			log.Error("error fetching file", "file", path.Join(dir, name), "error", err)
+			continue
+		}
+		if backendRegexp.Match(data) {
+			return []string{""}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,373 @@
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/log"
+)
+
+// The types below follow the JSON output format of `terraform show -json`,
+// see https://developer.hashicorp.com/terraform/internals/json-format
+
+type plan struct {
+	FormatVersion   string            `json:"format_version"`
+	ResourceChanges []resourceChange  `json:"resource_changes"`
+	OutputChanges   map[string]change `json:"output_changes"`
+}
+
+type resourceChange struct {
+	Address      string `json:"address"`
+	Mode         string `json:"mode"`
+	Type         string `json:"type"`
+	Name         string `json:"name"`
+	Change       change `json:"change"`
+	ActionReason string `json:"action_reason"`
+}
+
+type change struct {
+	Actions         []string `json:"actions"`
+	Before          any      `json:"before"`
+	After           any      `json:"after"`
+	AfterUnknown    any      `json:"after_unknown"`
+	BeforeSensitive any      `json:"before_sensitive"`
+	AfterSensitive  any      `json:"after_sensitive"`
+	ReplacePaths    [][]any  `json:"replace_paths"`
+}
+
+// Action is the summarized action performed on a resource.
+type Action string
+
+const (
+	actionNoOp    Action = "no-op"
+	actionCreate  Action = "create"
+	actionRead    Action = "read"
+	actionUpdate  Action = "update"
+	actionReplace Action = "replace"
+	actionDelete  Action = "delete"
+	actionForget  Action = "forget"
+)
+
+const (
+	sensitiveValue  = "(sensitive value)"
+	knownAfterApply = "(known after apply)"
+)
+
+// summaryActions is the order in which actions are listed in the summary.
+var summaryActions = []struct {
+	action Action
+	label  string
+}{
+	{actionCreate, "to create"},
+	{actionUpdate, "to update"},
+	{actionReplace, "to replace"},
+	{actionDelete, "to destroy"},
+	{actionRead, "to read"},
+	{actionForget, "to forget"},
+}
+
+var identifierRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// Formatter renders Terraform plans.
+type Formatter struct {
+	input []byte
+}
+
+// NewFormatter returns a Formatter for the output of `terraform show -json`.
+func NewFormatter(input []byte) *Formatter {
+	return &Formatter{input}
+}
+
+// Format renders the plan as Markdown. If the input is not a JSON plan, it's
+// returned in a diff code block.
+func (f *Formatter) Format() (string, error) {
+	p := new(plan)
+	if err := json.Unmarshal(f.input, p); err != nil || p.FormatVersion == "" {
+		log.Debug("output is not a JSON plan, rendering as is", "err", err)
+		return fmt.Sprintf("```diff\n%s\n```", string(f.input)), nil
+	}
+	log.Debug("parsed plan", "plan", p)
+
+	counts := make(map[Action]int)
+	resources := make([]resourceChange, 0)
+	for _, rc := range p.ResourceChanges {
+		a := summarize(rc.Change.Actions)
+		if a == actionNoOp {
+			continue
+		}
+		counts[a]++
+		resources = append(resources, rc)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Plan: %s\n", formatSummary(counts)))
+
+	if outputs := formatOutputChanges(p.OutputChanges); outputs != "" {
+		sb.WriteString("\n**Changes to Outputs:**\n\n```diff\n")
+		sb.WriteString(outputs)
+		sb.WriteString("```\n")
+	}
+
+	if len(resources) == 0 {
+		sb.WriteString("\nNo changes. Your infrastructure matches the configuration.\n")
+		return sb.String(), nil
+	}
+
+	sb.WriteString("\n")
+	for _, rc := range resources {
+		sb.WriteString(formatResourceChange(rc))
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n"), nil
+}
+
+// summarize returns the action for the list of actions of a change.
+func summarize(actions []string) Action {
+	switch {
+	case len(actions) == 2 && slices.Contains(actions, "create") && slices.Contains(actions, "delete"):
+		return actionReplace
+	case len(actions) == 1:
+		return Action(actions[0])
+	default:
+		return actionNoOp
+	}
+}
+
+func formatSummary(counts map[Action]int) string {
+	parts := make([]string, 0)
+	for _, s := range summaryActions {
+		if n := counts[s.action]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, s.label))
+		}
+	}
+	if len(parts) == 0 {
+		return "no changes"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatOutputChanges(outputs map[string]change) string {
+	names := make([]string, 0, len(outputs))
+	for name := range outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	for _, name := range names {
+		c := outputs[name]
+		before := leafValue(c.Before, c.BeforeSensitive, nil)
+		after := leafValue(c.After, c.AfterSensitive, c.AfterUnknown)
+		switch summarize(c.Actions) {
+		case actionCreate:
+			sb.WriteString(fmt.Sprintf("+ %s = %s\n", name, after))
+		case actionDelete:
+			sb.WriteString(fmt.Sprintf("- %s = %s\n", name, before))
+		case actionUpdate, actionReplace:
+			if before == sensitiveValue && after == sensitiveValue {
+				sb.WriteString(fmt.Sprintf("~ %s = %s\n", name, sensitiveValue))
+			} else {
+				sb.WriteString(fmt.Sprintf("~ %s = %s -> %s\n", name, before, after))
+			}
+		}
+	}
+	return sb.String()
+}
+
+func formatResourceChange(rc resourceChange) string {
+	action := summarize(rc.Change.Actions)
+	prefix := actionPrefix(action)
+	address := rc.Address
+	if rc.Mode == "data" && !strings.Contains(address, "data.") {
+		address = "data." + address
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("<details><summary><code>%s %s</code>", prefix, address))
+	if action == actionReplace && rc.ActionReason != "" {
+		sb.WriteString(fmt.Sprintf(" (%s)", strings.ReplaceAll(rc.ActionReason, "_", " ")))
+	}
+	sb.WriteString("</summary>\n\n```diff\n")
+	sb.WriteString(formatAttributes(action, rc.Change))
+	sb.WriteString("```\n</details>\n")
+	return sb.String()
+}
+
+func actionPrefix(a Action) string {
+	switch a {
+	case actionCreate:
+		return "+"
+	case actionDelete:
+		return "-"
+	case actionReplace:
+		return "-/+"
+	case actionRead:
+		return "<="
+	default:
+		return "~"
+	}
+}
+
+// formatAttributes renders the attribute diff of a resource change.
+func formatAttributes(action Action, c change) string {
+	before := flatten(c.Before, c.BeforeSensitive, nil)
+	after := flatten(c.After, c.AfterSensitive, c.AfterUnknown)
+	forcesReplacement := make(map[string]bool)
+	for _, p := range c.ReplacePaths {
+		forcesReplacement[formatPath(p)] = true
+	}
+
+	paths := make([]string, 0, len(before)+len(after))
+	for p := range before {
+		paths = append(paths, p)
+	}
+	for p := range after {
+		if _, ok := before[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+
+	var sb strings.Builder
+	for _, p := range paths {
+		oldValue, hasOld := before[p]
+		newValue, hasNew := after[p]
+		var line string
+		switch {
+		case action == actionDelete || (hasOld && !hasNew):
+			line = fmt.Sprintf("-   %s = %s", p, oldValue.text)
+		case action == actionCreate || action == actionRead || (!hasOld && hasNew):
+			line = fmt.Sprintf("+   %s = %s", p, newValue.text)
+		case oldValue.text != newValue.text:
+			line = fmt.Sprintf("!   %s = %s -> %s", p, oldValue.text, newValue.text)
+		case oldValue.text == sensitiveValue && !reflect.DeepEqual(oldValue.value, newValue.value):
+			// Both are masked, so only the change is shown.
+			line = fmt.Sprintf("!   %s = %s", p, sensitiveValue)
+		default:
+			continue
+		}
+		if forcesReplacement[p] {
+			line += " # forces replacement"
+		}
+		sb.WriteString(line + "\n")
+	}
+	if sb.Len() == 0 {
+		sb.WriteString("    (no attribute changes)\n")
+	}
+	return sb.String()
+}
+
+// leaf is an attribute's value, and its text with the sensitive values
+// masked and the unknown ones marked.
+type leaf struct {
+	text  string
+	value any
+}
+
+// flatten returns the leaf values of an attribute tree keyed by their path.
+func flatten(value, sensitive, unknown any) map[string]leaf {
+	out := make(map[string]leaf)
+	flattenInto(out, "", value, sensitive, unknown)
+	return out
+}
+
+func flattenInto(out map[string]leaf, path string, value, sensitive, unknown any) {
+	if sensitive == true || unknown == true {
+		if path != "" {
+			out[path] = leaf{leafValue(value, sensitive, unknown), value}
+		}
+		return
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		sm, _ := sensitive.(map[string]any)
+		um, _ := unknown.(map[string]any)
+		if len(v) == 0 && len(um) == 0 && path != "" {
+			out[path] = leaf{"{}", v}
+		}
+		for k, val := range v {
+			flattenInto(out, joinKey(path, k), val, sm[k], um[k])
+		}
+		// Attributes that are only known after apply are not in the value.
+		for k, u := range um {
+			if _, ok := v[k]; !ok {
+				flattenInto(out, joinKey(path, k), nil, sm[k], u)
+			}
+		}
+	case []any:
+		sl, _ := sensitive.([]any)
+		ul, _ := unknown.([]any)
+		if len(v) == 0 && path != "" {
+			out[path] = leaf{"[]", v}
+		}
+		for i, val := range v {
+			flattenInto(out, fmt.Sprintf("%s[%d]", path, i), val, index(sl, i), index(ul, i))
+		}
+	default:
+		if path != "" && value != nil {
+			out[path] = leaf{leafValue(value, sensitive, unknown), value}
+		}
+	}
+}
+
+func leafValue(value, sensitive, unknown any) string {
+	switch {
+	case sensitive == true:
+		return sensitiveValue
+	case unknown == true:
+		return knownAfterApply
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return strconv.Quote(v)
+	case map[string]any, []any:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(b)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+func joinKey(path, key string) string {
+	if !identifierRegexp.MatchString(key) {
+		return fmt.Sprintf("%s[%s]", path, strconv.Quote(key))
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// formatPath formats a replace path, i.e. ["tags", "env"] or ["disk", 0].
+func formatPath(p []any) string {
+	path := ""
+	for _, seg := range p {
+		switch s := seg.(type) {
+		case string:
+			path = joinKey(path, s)
+		case float64:
+			path = fmt.Sprintf("%s[%d]", path, int(s))
+		}
+	}
+	return path
+}
+
+func index(l []any, i int) any {
+	if i < len(l) {
+		return l[i]
+	}
+	return nil
+}
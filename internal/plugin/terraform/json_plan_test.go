@@ -0,0 +1,104 @@
+package terraform
+
+import (
+	"strings"
+	"testing"
+)
+
+const showJSON = `{
+  "format_version": "1.2",
+  "resource_changes": [
+    {
+      "address": "aws_s3_bucket.logs", "mode": "managed", "type": "aws_s3_bucket", "name": "logs",
+      "change": {
+        "actions": ["create"], "before": null,
+        "after": {"bucket": "logs", "tags": {"env": "dev"}},
+        "after_unknown": {"arn": true, "tags": {}},
+        "before_sensitive": false, "after_sensitive": {"tags": {}}
+      }
+    },
+    {
+      "address": "aws_instance.web", "mode": "managed", "type": "aws_instance", "name": "web",
+      "change": {
+        "actions": ["update"],
+        "before": {"instance_type": "t2.micro", "ami": "ami-1", "user_data": "secret", "root_password": "pw"},
+        "after": {"instance_type": "t3.micro", "ami": "ami-1", "user_data": "secret2", "root_password": "pw"},
+        "after_unknown": {},
+        "before_sensitive": {"user_data": true, "root_password": true},
+        "after_sensitive": {"user_data": true, "root_password": true}
+      }
+    },
+    {
+      "address": "aws_db_instance.db", "mode": "managed", "type": "aws_db_instance", "name": "db",
+      "action_reason": "replace_because_cannot_update",
+      "change": {
+        "actions": ["delete", "create"],
+        "before": {"engine_version": "13"}, "after": {"engine_version": "15"},
+        "after_unknown": {}, "before_sensitive": {}, "after_sensitive": {},
+        "replace_paths": [["engine_version"]]
+      }
+    },
+    {
+      "address": "aws_sqs_queue.jobs", "mode": "managed", "type": "aws_sqs_queue", "name": "jobs",
+      "change": {"actions": ["delete"], "before": {"name": "jobs"}, "after": null, "before_sensitive": {}, "after_sensitive": false}
+    },
+    {
+      "address": "aws_vpc.main", "mode": "managed", "type": "aws_vpc", "name": "main",
+      "change": {"actions": ["no-op"], "before": {"cidr_block": "10.0.0.0/16"}, "after": {"cidr_block": "10.0.0.0/16"}}
+    }
+  ],
+  "output_changes": {
+    "ip": {"actions": ["update"], "before": "1.2.3.4", "after": null, "after_unknown": true, "before_sensitive": false, "after_sensitive": false},
+    "password": {"actions": ["create"], "before": null, "after": "hunter2", "after_unknown": false, "before_sensitive": false, "after_sensitive": true},
+    "token": {"actions": ["update"], "before": "t0k3n", "after": "t0k3n2", "after_unknown": false, "before_sensitive": true, "after_sensitive": true}
+  }
+}`
+
+func TestFormat(t *testing.T) {
+	out, err := NewFormatter([]byte(showJSON)).Format()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"Plan: 1 to create, 1 to update, 1 to replace, 1 to destroy\n",
+		"~ ip = \"1.2.3.4\" -> (known after apply)\n",
+		"+ password = (sensitive value)\n",
+		"~ token = (sensitive value)\n",
+		"<details><summary><code>+ aws_s3_bucket.logs</code></summary>",
+		"+   arn = (known after apply)\n",
+		"+   tags.env = \"dev\"\n",
+		"<details><summary><code>~ aws_instance.web</code></summary>",
+		"!   instance_type = \"t2.micro\" -> \"t3.micro\"\n",
+		"!   user_data = (sensitive value)\n",
+		"<details><summary><code>-/+ aws_db_instance.db</code> (replace because cannot update)</summary>",
+		"!   engine_version = \"13\" -> \"15\" # forces replacement\n",
+		"-   name = \"jobs\"\n",
+	}
+	for _, e := range expected {
+		if !strings.Contains(out, e) {
+			t.Errorf("expected output to contain %q, got:\n%s", e, out)
+		}
+	}
+
+	unexpected := []string{"hunter2", "t0k3n", "ami-1", "secret", "root_password", "aws_vpc.main"}
+	for _, u := range unexpected {
+		if strings.Contains(out, u) {
+			t.Errorf("expected output not to contain %q, got:\n%s", u, out)
+		}
+	}
+}
+
+func TestFormatNoChanges(t *testing.T) {
+	out, _ := NewFormatter([]byte(`{"format_version": "1.2", "resource_changes": []}`)).Format()
+	if !strings.HasPrefix(out, "Plan: no changes\n") {
+		t.Errorf("expected no changes, got %q", out)
+	}
+}
+
+func TestFormatNotJSON(t *testing.T) {
+	out, _ := NewFormatter([]byte("Error: Invalid provider configuration")).Format()
+	if out != "```diff\nError: Invalid provider configuration\n```" {
+		t.Errorf("expected raw output in a code block, got %q", out)
+	}
+}
@@ -0,0 +1,92 @@
+package plugin
+
+import (
+	"path"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/ivanvc/turnip/internal/plugin/terraform"
+	"github.com/ivanvc/turnip/internal/plugin/terragrunt"
+	"github.com/ivanvc/turnip/internal/yaml"
+)
+
+// Terragrunt is a plugin.
+type Terragrunt struct{}
+
+func init() {
+	Register(Terragrunt{})
+}
+
+// Name conforms to the Plugin interface.
+func (t Terragrunt) Name() string {
+	return yaml.TerragruntAdapter{}.GetName()
+}
+
+// PlanName conforms to the Plugin interface.
+func (t Terragrunt) PlanName() string {
+	return "plan"
+}
+
+// LiftName conforms to the Plugin interface.
+func (t Terragrunt) LiftName() string {
+	return "apply"
+}
+
+// WorkspaceFlag conforms to the Plugin interface.
+func (t Terragrunt) WorkspaceFlag() Flag {
+	return Flag{Name: "workspace", Shorthand: "w", Usage: "the workspace to use"}
+}
+
+// AutoPlan conforms to the Plugin interface.
+func (t Terragrunt) AutoPlan(project *yaml.Project) bool {
+	return project.AutoPlan
+}
+
+// FormatDiff conforms to the Plugin interface. Single module plans are
+// reported as JSON, run-all plans as text.
+func (t Terragrunt) FormatDiff(diff []byte) (string, error) {
+	f := terraform.NewFormatter(diff)
+	return f.Format()
+}
+
+// DefaultWhenModified conforms to the WhenModifiedDefaulter interface. Besides
+// the project's files, it includes the files in its include blocks, and the
+// modules it depends on.
+func (t Terragrunt) DefaultWhenModified(project *yaml.Project, files *Files) []string {
+	rules := []string{"./**/*"}
+	data, err := files.Fetch(path.Join(project.Dir, terragrunt.DefaultConfigName))
+	if err != nil || len(data) == 0 {
+		log.Debug("no terragrunt configuration found", "dir", project.Dir, "error", err)
+		return rules
+	}
+
+	cfg := terragrunt.Parse(data)
+	for _, inc := range cfg.Includes {
+		if !inc.InParentFolders {
+			rules = append(rules, inc.Path)
+			continue
+		}
+		if rel, ok := findInParentFolders(project.Dir, inc.Path, files); ok {
+			rules = append(rules, rel)
+		}
+	}
+	for _, dep := range cfg.Dependencies {
+		rules = append(rules, path.Join(dep.Path, "**", "*"))
+	}
+	return rules
+}
+
+// findInParentFolders looks for the file in the parent directories of dir, and
+// returns its path relative to dir.
+func findInParentFolders(dir, name string, files *Files) (string, bool) {
+	rel := ".."
+	for d := path.Dir(path.Clean(dir)); ; d = path.Dir(d) {
+		if files.Has(path.Join(d, name)) {
+			return path.Join(rel, name), true
+		}
+		if d == "." || d == "/" {
+			return "", false
+		}
+		rel = path.Join(rel, "..")
+	}
+}
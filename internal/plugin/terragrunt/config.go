@@ -0,0 +1,120 @@
+package terragrunt
+
+import (
+	"regexp"
+	"strings"
+)
+
+// DefaultConfigName is the name of Terragrunt's configuration file, and the
+// file find_in_parent_folders looks for when called without arguments.
+const DefaultConfigName = "terragrunt.hcl"
+
+// Reference is a path referenced from a terragrunt.hcl.
+type Reference struct {
+	// Path is relative to the directory of the terragrunt.hcl, unless
+	// InParentFolders is set, in which case it's the name of the file to look
+	// for in the parent directories.
+	Path            string
+	InParentFolders bool
+}
+
+// Config holds the parts of a terragrunt.hcl that reference other files.
+type Config struct {
+	// Includes are the paths of the include blocks.
+	Includes []Reference
+	// Dependencies are the module directories of the dependency and
+	// dependencies blocks.
+	Dependencies []Reference
+}
+
+var (
+	blockRegexp           = regexp.MustCompile(`(?m)^\s*(include|dependency|dependencies)\b[^{\n]*\{`)
+	attributeRegexp       = regexp.MustCompile(`(?m)^\s*(path|config_path)\s*=\s*(.+)$`)
+	pathsRegexp           = regexp.MustCompile(`(?s)\bpaths\s*=\s*\[(.*?)\]`)
+	findInParentRegexp    = regexp.MustCompile(`^find_in_parent_folders\(\s*(?:"([^"]*)")?\s*\)$`)
+	stringRegexp          = regexp.MustCompile(`"([^"]*)"`)
+	terragruntDirRegexp   = regexp.MustCompile(`^\$\{get_terragrunt_dir\(\)\}/`)
+	interpolationRegexp   = regexp.MustCompile(`\$\{`)
+	lineCommentRegexp     = regexp.MustCompile(`(?m)^\s*(#|//).*$`)
+	trailingCommentRegexp = regexp.MustCompile(`\s+(#|//)[^"]*$`)
+)
+
+// Parse extracts the include and dependency paths of a terragrunt.hcl. It
+// doesn't evaluate HCL, so only literal paths and calls to
+// find_in_parent_folders are understood, the rest are ignored.
+func Parse(data []byte) Config {
+	src := lineCommentRegexp.ReplaceAllString(string(data), "")
+
+	var cfg Config
+	for _, loc := range blockRegexp.FindAllStringSubmatchIndex(src, -1) {
+		kind := src[loc[2]:loc[3]]
+		body := blockBody(src[loc[1]:])
+		if kind == "dependencies" {
+			if m := pathsRegexp.FindStringSubmatch(body); m != nil {
+				for _, s := range stringRegexp.FindAllString(m[1], -1) {
+					if ref, ok := parseReference(s); ok {
+						cfg.Dependencies = append(cfg.Dependencies, ref)
+					}
+				}
+			}
+			continue
+		}
+		for _, m := range attributeRegexp.FindAllStringSubmatch(body, -1) {
+			value := strings.TrimSpace(trailingCommentRegexp.ReplaceAllString(m[2], ""))
+			switch {
+			case kind == "include" && m[1] == "path":
+				if ref, ok := parseReference(value); ok {
+					cfg.Includes = append(cfg.Includes, ref)
+				}
+			case kind == "dependency" && m[1] == "config_path":
+				if ref, ok := parseReference(value); ok {
+					cfg.Dependencies = append(cfg.Dependencies, ref)
+				}
+			}
+		}
+	}
+	return cfg
+}
+
+// blockBody returns the body of a block, up to its matching closing brace.
+func blockBody(src string) string {
+	depth := 1
+	inString := false
+	for i := 0; i < len(src); i++ {
+		switch c := src[i]; {
+		case c == '\\' && inString:
+			i++
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				return src[:i]
+			}
+		}
+	}
+	return src
+}
+
+func parseReference(value string) (Reference, bool) {
+	if m := findInParentRegexp.FindStringSubmatch(value); m != nil {
+		name := m[1]
+		if name == "" {
+			name = DefaultConfigName
+		}
+		return Reference{Path: name, InParentFolders: true}, true
+	}
+
+	m := stringRegexp.FindStringSubmatch(value)
+	if m == nil || m[0] != value {
+		return Reference{}, false
+	}
+	path := terragruntDirRegexp.ReplaceAllString(m[1], "")
+	if interpolationRegexp.MatchString(path) {
+		return Reference{}, false
+	}
+	return Reference{Path: path}, true
+}
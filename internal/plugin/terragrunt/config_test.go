@@ -0,0 +1,63 @@
+package terragrunt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	input := `# Root configuration
+include "root" {
+  path = find_in_parent_folders()
+}
+
+include "env" {
+  path   = "${get_terragrunt_dir()}/../env.hcl" # shared inputs
+  expose = true
+}
+
+include "region" {
+  path = find_in_parent_folders("region.hcl")
+}
+
+include "computed" {
+  path = "${local.common}/common.hcl"
+}
+
+dependency "vpc" {
+  config_path = "../vpc"
+
+  mock_outputs = {
+    vpc_id = "vpc-{mock}"
+  }
+}
+
+dependencies {
+  paths = [
+    "../iam",
+    "../kms",
+  ]
+}
+
+inputs = {
+  path = "not/an/include"
+}
+`
+	expected := Config{
+		Includes: []Reference{
+			{Path: "terragrunt.hcl", InParentFolders: true},
+			{Path: "../env.hcl"},
+			{Path: "region.hcl", InParentFolders: true},
+		},
+		Dependencies: []Reference{
+			{Path: "../vpc"},
+			{Path: "../iam"},
+			{Path: "../kms"},
+		},
+	}
+
+	actual := Parse([]byte(input))
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %+v, got %+v", expected, actual)
+	}
+}
@@ -0,0 +1,45 @@
+package plugin
+
+import (
+	"github.com/ivanvc/turnip/internal/plugin/terraform"
+	"github.com/ivanvc/turnip/internal/yaml"
+)
+
+// Tofu is a plugin. OpenTofu plans share Terraform's JSON format, so they're
+// rendered with the same formatter.
+type Tofu struct{}
+
+func init() {
+	Register(Tofu{})
+}
+
+// Name conforms to the Plugin interface.
+func (t Tofu) Name() string {
+	return yaml.TofuAdapter{}.GetName()
+}
+
+// PlanName conforms to the Plugin interface.
+func (t Tofu) PlanName() string {
+	return "plan"
+}
+
+// LiftName conforms to the Plugin interface.
+func (t Tofu) LiftName() string {
+	return "apply"
+}
+
+// WorkspaceFlag conforms to the Plugin interface.
+func (t Tofu) WorkspaceFlag() Flag {
+	return Flag{Name: "workspace", Shorthand: "w", Usage: "the workspace to use"}
+}
+
+// AutoPlan conforms to the Plugin interface.
+func (t Tofu) AutoPlan(project *yaml.Project) bool {
+	return project.AutoPlan
+}
+
+// FormatDiff conforms to the Plugin interface.
+func (t Tofu) FormatDiff(diff []byte) (string, error) {
+	f := terraform.NewFormatter(diff)
+	return f.Format()
+}
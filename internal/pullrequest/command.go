@@ -0,0 +1,162 @@
+package pullrequest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+
+	"github.com/ivanvc/turnip/internal/common"
+	"github.com/ivanvc/turnip/internal/plugin"
+	"github.com/ivanvc/turnip/internal/vcs"
+	"github.com/ivanvc/turnip/internal/yaml"
+)
+
+// RunCommand runs the /turnip command in the comment's body on the pull
+// request, and comments its output, or its error.
+func RunCommand(common *common.Common, client vcs.PullRequestClient, pr *vcs.PullRequest, user, body string) error {
+	cmd := NewCommand(common, client, pr, user)
+	var out bytes.Buffer
+	var in bytes.Reader
+	cmd.SetIn(&in)
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs(strings.Fields(body)[1:])
+	if err := cmd.Execute(); err != nil {
+		log.Error("Error executing command", "error", err)
+		if err := client.CreateComment(pr.CommentsURL, fmt.Sprintf("Error executing command:\n\n```\n%s\n```", ErrorDetails(err))); err != nil {
+			log.Error("Error creating comment", "error", err)
+		}
+		return err
+	}
+
+	if out.Len() > 0 {
+		if err := client.CreateComment(pr.CommentsURL, fmt.Sprintf("```\n%s\n```", out.String())); err != nil {
+			log.Error("Error creating comment", "error", err)
+		}
+	}
+	return nil
+}
+
+// NewCommand returns the /turnip command, run by the user on the pull request.
+func NewCommand(common *common.Common, client vcs.PullRequestClient, pr *vcs.PullRequest, user string) *cobra.Command {
+	root := &cobra.Command{
+		Use:               "/turnip",
+		Short:             "Turnip is an IaC automation bot",
+		CompletionOptions: cobra.CompletionOptions{DisableDefaultCmd: true},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Usage()
+		},
+	}
+
+	root.AddCommand(getCobraCmd(common, client, pr, user, "plot"))
+	root.AddCommand(getCobraCmd(common, client, pr, user, "lift"))
+	return root
+}
+
+func getCobraCmd(common *common.Common, client vcs.PullRequestClient, pr *vcs.PullRequest, user, cmdName string) *cobra.Command {
+	var project, directory, description string
+	var aliases []string
+
+	switch cmdName {
+	case "plot":
+		aliases = []string{"pre"}
+		description = "Plot changes in your infrastructure"
+	case "lift":
+		aliases = []string{"deploy"}
+		description = "Lift applies changes in your infrastructure"
+	}
+
+	// Each plugin contributes its command name as an alias, and its flag to
+	// select the workspace. Plugins may share the same flag.
+	workspaces := make(map[string]*string)
+	flags := make([]plugin.Flag, 0)
+	for _, p := range plugin.All() {
+		alias := p.PlanName()
+		if cmdName == "lift" {
+			alias = p.LiftName()
+		}
+		if alias != cmdName && !slices.Contains(aliases, alias) {
+			aliases = append(aliases, alias)
+		}
+
+		f := p.WorkspaceFlag()
+		if _, ok := workspaces[f.Name]; ok {
+			continue
+		}
+		workspaces[f.Name] = new(string)
+		flags = append(flags, f)
+	}
+
+	var cmd = &cobra.Command{
+		Use:     cmdName,
+		Aliases: aliases,
+		Short:   description,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if pr == nil {
+				return errors.New("I can only work on pull requests")
+			}
+			cfg, projects, err := Projects(common, client, pr, false)
+			if err != nil {
+				log.Error("Error getting list of projects", "error", err)
+				return err
+			}
+			log.Debug("projects to run", "projects", projects)
+
+			if len(project) > 0 {
+				projects = slices.DeleteFunc(projects, func(p *yaml.Project) bool {
+					return p.Name != project
+				})
+			}
+			log.Debug("projects to run after project filter", "projects", projects)
+
+			if len(directory) > 0 {
+				projects = slices.DeleteFunc(projects, func(p *yaml.Project) bool {
+					return p.Dir != directory
+				})
+			}
+			log.Debug("projects to run after directory filter", "projects", projects)
+
+			for name, workspace := range workspaces {
+				if len(*workspace) == 0 {
+					continue
+				}
+				projects = slices.DeleteFunc(projects, func(p *yaml.Project) bool {
+					pl, err := plugin.ForProject(p)
+					if err != nil {
+						return true
+					}
+					return pl.WorkspaceFlag().Name != name || p.GetWorkspace() != *workspace
+				})
+			}
+			log.Debug("projects to run after workspace filter", "projects", projects)
+
+			if len(projects) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No projects to "+cmdName)
+				return nil
+			}
+
+			var extraArgs string
+			if l := cmd.ArgsLenAtDash(); l > 0 {
+				extraArgs = strings.Join(args[l:], " ")
+			}
+			return Run(common, client, cmdName, extraArgs, user, pr, cfg, projects)
+		},
+	}
+	cmd.Flags().StringVarP(&project, "project", "p", project, "the name of the project")
+	cmd.Flags().StringVarP(&directory, "directory", "d", directory, "the directory containing the IaC")
+	names := make([]string, 0, len(flags))
+	for _, f := range flags {
+		cmd.Flags().StringVarP(workspaces[f.Name], f.Name, f.Shorthand, "", f.Usage)
+		names = append(names, f.Name)
+	}
+	if len(names) > 1 {
+		cmd.MarkFlagsMutuallyExclusive(names...)
+	}
+
+	return cmd
+}
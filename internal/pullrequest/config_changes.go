@@ -0,0 +1,104 @@
+package pullrequest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bluekeyes/go-gitdiff/gitdiff"
+	"github.com/charmbracelet/log"
+
+	"github.com/ivanvc/turnip/internal/discovery"
+	"github.com/ivanvc/turnip/internal/vcs"
+	"github.com/ivanvc/turnip/internal/yaml"
+)
+
+const pendingMarkerPrefix = "<!-- turnip:pending-config sha="
+
+// approvedConfig returns the configuration from the pull request's head if it
+// changes the base's one, and its head commit is approved. Otherwise, it
+// comments the pending changes on the pull request, and returns the base's.
+func approvedConfig(client vcs.PullRequestClient, pr *vcs.PullRequest, base yaml.Config, changes []*gitdiff.File, head discovery.Source, policy yaml.Policy) (yaml.Config, error) {
+	pending := configChanges(base, changes)
+	if len(pending) == 0 {
+		return base, nil
+	}
+
+	approved, err := client.IsApproved(pr)
+	if err != nil {
+		log.Error("error listing reviews", "error", err)
+		return base, err
+	}
+	if approved {
+		log.Info("configuration changes approved, loading it from head", "pr", pr.URL)
+		return discovery.LoadConfig(head, policy)
+	}
+
+	log.Info("configuration changes pending approval, loading it from base", "pr", pr.URL)
+	if err := commentPendingChanges(client, pr, pending); err != nil {
+		log.Error("error commenting pending configuration changes", "error", err)
+	}
+	return base, nil
+}
+
+// commentPendingChanges comments the configuration changes pending approval.
+// There's a single comment per pull request, updated when its head changes.
+func commentPendingChanges(client vcs.Client, pr *vcs.PullRequest, pending []*gitdiff.File) error {
+	marker := pendingMarkerPrefix + pr.Head.SHA + " -->\n"
+	var sb strings.Builder
+	sb.WriteString(marker)
+	sb.WriteString("This pull request changes the turnip configuration. The changes won't take effect until its latest commit is approved, the configuration from the base branch is used until then.\n\n```diff\n")
+	for _, f := range pending {
+		sb.WriteString(formatFileDiff(f))
+	}
+	sb.WriteString("```")
+
+	comments, err := client.ListComments(pr.CommentsURL)
+	if err != nil {
+		return err
+	}
+	for i := len(comments) - 1; i >= 0; i-- {
+		c := comments[i]
+		if !strings.HasPrefix(c.Body, pendingMarkerPrefix) {
+			continue
+		}
+		if strings.HasPrefix(c.Body, marker) {
+			return nil
+		}
+		return client.UpdateComment(c.URL, sb.String())
+	}
+	return client.CreateComment(pr.CommentsURL, sb.String())
+}
+
+// configChanges returns the changes to the configuration files.
+func configChanges(cfg yaml.Config, changes []*gitdiff.File) []*gitdiff.File {
+	out := make([]*gitdiff.File, 0)
+	for _, f := range changes {
+		if discovery.IsConfigFile(cfg, f.OldName) || discovery.IsConfigFile(cfg, f.NewName) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// formatFileDiff returns the unified diff of the file.
+func formatFileDiff(f *gitdiff.File) string {
+	var sb strings.Builder
+	oldName, newName := "a/"+f.OldName, "b/"+f.NewName
+	if f.IsNew {
+		oldName = "/dev/null"
+	}
+	if f.IsDelete {
+		newName = "/dev/null"
+	}
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+	for _, frag := range f.TextFragments {
+		sb.WriteString(strings.TrimSpace(frag.Header()) + "\n")
+		for _, l := range frag.Lines {
+			sb.WriteString(l.String())
+			if l.NoEOL() {
+				sb.WriteString("\n")
+			}
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,101 @@
+package pullrequest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bluekeyes/go-gitdiff/gitdiff"
+
+	"github.com/ivanvc/turnip/internal/vcs"
+	"github.com/ivanvc/turnip/internal/vcs/vcstest"
+	"github.com/ivanvc/turnip/internal/yaml"
+)
+
+const configDiff = `diff --git a/turnip.yaml b/turnip.yaml
+index 1111111..2222222 100644
+--- a/turnip.yaml
++++ b/turnip.yaml
+@@ -1,3 +1,3 @@
+ version: v1alpha1
+-include: []
++include: [infra/**/turnip.project.yaml]
+ projects: []
+diff --git a/infra/main.tf b/infra/main.tf
+index 3333333..4444444 100644
+--- a/infra/main.tf
++++ b/infra/main.tf
+@@ -1 +1 @@
+-resource "a" "b" {}
++resource "a" "c" {}
+diff --git a/infra/net/turnip.project.yaml b/infra/net/turnip.project.yaml
+new file mode 100644
+index 0000000..5555555
+--- /dev/null
++++ b/infra/net/turnip.project.yaml
+@@ -0,0 +1 @@
++projects: []
+`
+
+func TestConfigChanges(t *testing.T) {
+	changes, _, err := gitdiff.Parse(strings.NewReader(configDiff))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfg := yaml.Config{Include: []string{"infra/**/turnip.project.yaml"}}
+	pending := configChanges(cfg, changes)
+	if len(pending) != 2 {
+		t.Fatalf("expected 2 configuration changes, got %d", len(pending))
+	}
+
+	var sb strings.Builder
+	for _, f := range pending {
+		sb.WriteString(formatFileDiff(f))
+	}
+	expected := `--- a/turnip.yaml
++++ b/turnip.yaml
+@@ -1,3 +1,3 @@
+ version: v1alpha1
+-include: []
++include: [infra/**/turnip.project.yaml]
+ projects: []
+--- /dev/null
++++ b/infra/net/turnip.project.yaml
+@@ -0,0 +1,1 @@
++projects: []
+`
+	if sb.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, sb.String())
+	}
+}
+
+func TestCommentPendingChanges(t *testing.T) {
+	changes, _, err := gitdiff.Parse(strings.NewReader(configDiff))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	client := &vcstest.Client{Comments: []vcs.Comment{{URL: "other", Body: "LGTM"}}}
+	pr := &vcs.PullRequest{Head: vcs.Ref{SHA: "abc"}}
+
+	// A second event for the same head, e.g. a /turnip command, doesn't
+	// comment again.
+	for i := 0; i < 2; i++ {
+		if err := commentPendingChanges(client, pr, changes[:1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if client.Created != 1 || client.Updated != 0 {
+		t.Fatalf("expected a single comment, got %d created and %d updated", client.Created, client.Updated)
+	}
+
+	pr.Head.SHA = "def"
+	if err := commentPendingChanges(client, pr, changes[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if client.Created != 1 || client.Updated != 1 {
+		t.Fatalf("expected the comment to be updated, got %d created and %d updated", client.Created, client.Updated)
+	}
+	if body := client.Comments[1].Body; !strings.HasPrefix(body, pendingMarkerPrefix+"def -->") {
+		t.Errorf("expected the comment to be for the new head, got:\n%s", body)
+	}
+}
@@ -0,0 +1,203 @@
+// Package pullrequest plots and lifts the projects changed by pull requests,
+// regardless of the VCS they come from.
+package pullrequest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bluekeyes/go-gitdiff/gitdiff"
+	"github.com/charmbracelet/log"
+
+	"github.com/ivanvc/turnip/internal/common"
+	"github.com/ivanvc/turnip/internal/config"
+	"github.com/ivanvc/turnip/internal/discovery"
+	"github.com/ivanvc/turnip/internal/plugin"
+	"github.com/ivanvc/turnip/internal/scheduler"
+	"github.com/ivanvc/turnip/internal/template"
+	"github.com/ivanvc/turnip/internal/trigger"
+	"github.com/ivanvc/turnip/internal/vcs"
+	"github.com/ivanvc/turnip/internal/yaml"
+)
+
+// Opened plots the projects the pull request auto plots, when it's opened or
+// pushed to. Configuration errors are commented on the pull request.
+func Opened(common *common.Common, client vcs.PullRequestClient, pr *vcs.PullRequest, user string) error {
+	cfg, projects, err := Projects(common, client, pr, true)
+	if err != nil {
+		var cfgErr *yaml.ConfigError
+		if errors.As(err, &cfgErr) {
+			body := fmt.Sprintf("Error loading the turnip configuration:\n\n```\n%s\n```", ErrorDetails(err))
+			if cerr := client.CreateComment(pr.CommentsURL, body); cerr != nil {
+				log.Error("error creating comment", "error", cerr)
+			}
+		}
+		return err
+	}
+
+	return Run(common, client, "plot", "", user, pr, cfg, projects)
+}
+
+// Run creates a job for every project. The jobs of the projects depending on
+// others start once their dependencies succeed. The extra args come from the
+// comment, so they are rendered with the restricted template.ExecuteArgs.
+func Run(common *common.Common, client vcs.PullRequestClient, cmdName, extraArgs, user string, pr *vcs.PullRequest, cfg yaml.Config, projects []*yaml.Project) error {
+	names := make([]string, 0, len(projects))
+	for _, prj := range projects {
+		name, err := plugin.CheckName(prj, cmdName)
+		if err != nil {
+			log.Error("error getting check name", "error", err)
+			return err
+		}
+		names = append(names, name)
+	}
+
+	jobs := make([]*scheduler.Job, 0, len(projects))
+	deps := make([][]int, len(projects))
+	for i, prj := range projects {
+		name := names[i]
+		var depNames []string
+		for j := range projects {
+			if cfg.DependsOn(*prj, *projects[j]) {
+				deps[i] = append(deps[i], j)
+				depNames = append(depNames, names[j])
+			}
+		}
+		// The status is created with its final pending description, as GitLab
+		// doesn't allow updating a pending status to pending.
+		description := "Queued"
+		if len(depNames) > 0 {
+			description = "Waiting for " + strings.Join(depNames, ", ")
+		}
+
+		tpl := template.New(*prj, template.Environment{
+			Repo:        pr.Base.Repo,
+			PullRequest: pr.Number,
+			HeadSHA:     pr.Head.SHA,
+			BaseRef:     pr.Base.Ref,
+			Commenter:   user,
+			Command:     cmdName,
+		})
+		rendered, err := tpl.Render(*prj)
+		if err != nil {
+			log.Error("error rendering project", "error", err)
+			return err
+		}
+		args, err := tpl.ExecuteArgs(extraArgs)
+		if err != nil {
+			log.Error("error rendering extra args", "error", err)
+			return err
+		}
+
+		checkURL, err := client.CreateCheckRun(pr.StatusesURL, pr.Head.SHA, name, description)
+		if err != nil {
+			log.Error("error creating check run", "error", err)
+			return err
+		}
+
+		log.Debug("scheduling job", "checkURL", checkURL)
+		jobs = append(jobs, &scheduler.Job{
+			ID:   scheduler.JobID(checkURL, name),
+			Name: name,
+			Start: func() error {
+				if err := common.Executor.CreateJob(cmdName, pr.CloneURL, pr.Head.Ref, pr.Head.SHA, pr.Base.Repo, checkURL, name, pr.CommentsURL, args, &rendered); err != nil {
+					log.Error("error creating job", "error", err)
+					return err
+				}
+				return nil
+			},
+			Skip: func(reason string) error {
+				return client.UpdateCheckRun(checkURL, name, "error", reason)
+			},
+			PullRequest: pr.URL,
+			SHA:         pr.Head.SHA,
+		})
+	}
+
+	// The IDs are known once the check runs of every job are created.
+	for i, job := range jobs {
+		for _, j := range deps[i] {
+			job.DependsOn = append(job.DependsOn, jobs[j].ID)
+		}
+	}
+
+	return common.Scheduler.Schedule(jobs)
+}
+
+// Projects returns the configuration, with the included and discovered
+// projects, and the projects modified by the pull request. The configuration
+// is loaded from the pull request's base if the server sets so for the
+// repository, or until its changes are approved.
+func Projects(common *common.Common, client vcs.PullRequestClient, pr *vcs.PullRequest, autoPlot bool) (yaml.Config, []*yaml.Project, error) {
+	output := make([]*yaml.Project, 0)
+	repo, _ := common.RepoConfig.Find(pr.Base.Repo)
+	src := client.NewSource(pr.Head)
+	cfgSrc := src
+	if repo.FromBase() {
+		cfgSrc = client.NewSource(pr.Base)
+	}
+	cfg, err := discovery.LoadConfig(cfgSrc, common.RepoConfig.Policy(repo))
+	if err != nil {
+		return cfg, output, err
+	}
+
+	diff, err := client.GetPullRequestDiff(pr)
+	if err != nil {
+		log.Error("error getting diff", "error", err)
+		return cfg, output, err
+	}
+	log.Debug("pr diff", "diff", string(diff))
+
+	b := bytes.NewReader(diff)
+	changes, _, err := gitdiff.Parse(b)
+	if err != nil {
+		log.Error("error parsing diff", "error", err)
+		return cfg, output, err
+	}
+
+	if repo.ConfigFrom == config.ConfigFromApproved {
+		if cfg, err = approvedConfig(client, pr, cfg, changes, src, common.RepoConfig.Policy(repo)); err != nil {
+			return cfg, output, err
+		}
+	}
+
+	if err := plugin.Validate(cfg); err != nil {
+		log.Error("error validating configuration", "error", err)
+		return cfg, output, err
+	}
+
+	triggers, err := trigger.Projects(cfg, trigger.ChangedPaths(changes), autoPlot, src)
+	if err != nil {
+		return cfg, output, err
+	}
+	for _, t := range triggers {
+		if t.Triggered() {
+			output = append(output, t.Project)
+		}
+	}
+	log.Debug("projects to plot", "projects", output)
+
+	return cfg, output, nil
+}
+
+// ErrorDetails returns the error's message, with the offending line if it's
+// an error in a configuration file.
+func ErrorDetails(err error) string {
+	var cfgErr *yaml.ConfigError
+	if errors.As(err, &cfgErr) {
+		return cfgErr.Details()
+	}
+	return err.Error()
+}
+
+// IsRepoAllowed returns whether the server's configuration allows turnip to
+// run on the repository.
+func IsRepoAllowed(common *common.Common, fullName string) bool {
+	if _, ok := common.RepoConfig.Find(fullName); !ok {
+		log.Info("ignoring repository not allowed by the server", "repo", fullName)
+		return false
+	}
+	return true
+}
@@ -2,39 +2,51 @@ package rpc
 
 import (
 	"context"
-	"fmt"
 	"net"
+	"sync"
 	"time"
 
 	"github.com/charmbracelet/log"
-	"golang.org/x/text/cases"
-	"golang.org/x/text/language"
 	"google.golang.org/grpc"
 
-	"github.com/ivanvc/turnip/internal/adapters/github"
+	"github.com/ivanvc/turnip/internal/comment"
 	"github.com/ivanvc/turnip/internal/common"
+	"github.com/ivanvc/turnip/internal/plugin"
+	"github.com/ivanvc/turnip/internal/scheduler"
+	"github.com/ivanvc/turnip/internal/vcs"
 	pb "github.com/ivanvc/turnip/pkg/turnip"
 )
 
 type Server struct {
 	pb.UnimplementedTurnipServer
-	listen       string
-	gitHubClient *github.Client
+	listen         string
+	vcsClient      vcs.Client
+	scheduler      *scheduler.Scheduler
+	summaryComment bool
+	commentOptions comment.Options
 
 	jobsFinished map[string]interface{}
+	summaryMu    sync.Mutex
 }
 
 func NewServer(common *common.Common) *Server {
 	return &Server{
-		listen:       common.Config.ListenRPC,
-		gitHubClient: common.GitHubClient,
+		listen:         common.Config.ListenRPC,
+		vcsClient:      common.VCSClient(),
+		scheduler:      common.Scheduler,
+		summaryComment: common.Config.SummaryComment,
+		commentOptions: comment.Options{
+			MaxOutputSize: common.Config.MaxOutputSize,
+			LogURL:        common.Config.OutputLogURL,
+			Format:        plugin.FormatOutput,
+		},
 		jobsFinished: make(map[string]interface{}),
 	}
 }
 
 func (s *Server) ReportJobStarted(ctx context.Context, in *pb.JobStartedRequest) (*pb.JobStartedReply, error) {
 	log.Debug("Received Job Started", "in", in)
-	err := s.gitHubClient.StartCheckRun(in.GetCheckUrl(), in.GetCheckName())
+	err := s.vcsClient.StartCheckRun(in.GetCheckUrl(), in.GetCheckName())
 	return &pb.JobStartedReply{}, err
 }
 
@@ -42,7 +54,7 @@ func (s *Server) ReportJobFinished(ctx context.Context, in *pb.JobFinishedReques
 	// TODO: Remove once we have a database
 	log.Debug("Received Job Finished", "in", in)
 	var err error
-	key := fmt.Sprintf("%s/%s", in.GetCheckUrl(), in.GetCheckName())
+	key := scheduler.JobID(in.GetCheckUrl(), in.GetCheckName())
 	if _, ok := s.jobsFinished[key]; !ok {
 		defer func() {
 			select {
@@ -54,6 +66,7 @@ func (s *Server) ReportJobFinished(ctx context.Context, in *pb.JobFinishedReques
 		}()
 		s.jobsFinished[key] = struct{}{}
 		err = s.reportJobFinished(in)
+		s.scheduler.Finished(key, in.GetStatus() == pb.JobStatus_SUCCEEDED)
 	}
 
 	return &pb.JobFinishedReply{}, err
@@ -68,28 +81,84 @@ func (s *Server) reportJobFinished(in *pb.JobFinishedRequest) error {
 	case pb.JobStatus_FAILED:
 		conclusion = "failure"
 	}
-	err := s.gitHubClient.FinishCheckRun(in.GetCheckUrl(), in.GetCheckName(), conclusion)
+	err := s.vcsClient.FinishCheckRun(in.GetCheckUrl(), in.GetCheckName(), conclusion)
 	if err != nil {
 		log.Error("Error finishing check run", "error", err)
 	}
-	comment := fmt.Sprintf(
-		"Ran %s for %s %s\n\nStatus: %s",
-		in.GetCommand(),
-		in.GetProjectDir(),
-		in.GetProjectWorkspace(),
-		cases.Title(language.English).String(in.GetStatus().String()),
-	)
-	// if project type == pulumi
-	comment += fmt.Sprintf("\n\n<details><summary>Show Output</summary>\n\n")
-	if len(in.GetOutput()) > 0 {
-		comment += fmt.Sprintf("```diff\n%s\n```\n", in.GetOutput())
+
+	if s.summaryComment {
+		return s.updateSummaryComment(in, comment.RenderTruncated(in, s.commentOptions, comment.MaxLength/2))
 	}
-	if in.GetError() != "" {
-		comment += fmt.Sprintf("Error:\n```\n%s\n```\n", in.GetError())
+
+	for _, body := range comment.RenderParts(in, s.commentOptions) {
+		if err := s.vcsClient.CreateComment(in.GetCommentsUrl(), body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateSummaryComment sets the job's section in the pull request summary
+// comment, creating the comment if it doesn't exist for the head commit yet.
+// Summaries from previous commits are minimized as outdated. Jobs of commits
+// that are no longer the head, e.g. that finish after a push, are dropped.
+func (s *Server) updateSummaryComment(in *pb.JobFinishedRequest, body string) error {
+	s.summaryMu.Lock()
+	defer s.summaryMu.Unlock()
+
+	head, err := s.vcsClient.HeadSHA(in.GetCommentsUrl())
+	if err != nil {
+		log.Error("Error getting head commit", "error", err)
+		return err
+	}
+	if head != "" && head != in.GetHeadSha() {
+		log.Info("Dropping the summary of a previous commit", "sha", in.GetHeadSha(), "head", head)
+		return nil
+	}
+
+	comments, err := s.vcsClient.ListComments(in.GetCommentsUrl())
+	if err != nil {
+		log.Error("Error listing comments", "error", err)
+		return err
+	}
+
+	var current *vcs.Comment
+	var summary *comment.Summary
+	for i, c := range comments {
+		sum, ok := comment.ParseSummary(c.Body)
+		if !ok || sum.Outdated {
+			continue
+		}
+		if sum.SHA == in.GetHeadSha() {
+			current, summary = &comments[i], sum
+			continue
+		}
+		s.minimizeSummaryComment(c, sum)
+	}
+
+	if summary == nil {
+		summary = comment.NewSummary(in.GetHeadSha())
+	}
+	summary.SetSection(comment.SectionKey(in.GetProjectName(), in.GetProjectDir(), in.GetProjectWorkspace()), body)
+	summary.Fit(comment.MaxLength)
+
+	if current == nil {
+		return s.vcsClient.CreateComment(in.GetCommentsUrl(), summary.String())
+	}
+	return s.vcsClient.UpdateComment(current.URL, summary.String())
+}
+
+// minimizeSummaryComment hides a summary from a previous commit, and flags it
+// as outdated so it's skipped in the future.
+func (s *Server) minimizeSummaryComment(c vcs.Comment, summary *comment.Summary) {
+	if err := s.vcsClient.MinimizeComment(c, "OUTDATED"); err != nil {
+		log.Error("Error minimizing comment", "error", err, "comment", c.URL)
+		return
+	}
+	summary.Outdated = true
+	if err := s.vcsClient.UpdateComment(c.URL, summary.String()); err != nil {
+		log.Error("Error updating comment", "error", err, "comment", c.URL)
 	}
-	comment += fmt.Sprintf("</details>")
-	err = s.gitHubClient.CreateComment(in.GetCommentsUrl(), comment)
-	return err
 }
 
 func (s *Server) Start() {
@@ -98,7 +167,10 @@ func (s *Server) Start() {
 		log.Fatal("Failed to listen", "error", err)
 	}
 
-	gs := grpc.NewServer()
+	gs := grpc.NewServer(
+		grpc.MaxRecvMsgSize(pb.MaxMessageSize),
+		grpc.MaxSendMsgSize(pb.MaxMessageSize),
+	)
 	pb.RegisterTurnipServer(gs, s)
 	log.Infof("Server listening at %v", lis.Addr())
 	if err := gs.Serve(lis); err != nil {
@@ -0,0 +1,49 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/ivanvc/turnip/internal/comment"
+	"github.com/ivanvc/turnip/internal/vcs"
+	"github.com/ivanvc/turnip/internal/vcs/vcstest"
+	pb "github.com/ivanvc/turnip/pkg/turnip"
+)
+
+func TestUpdateSummaryComment(t *testing.T) {
+	tt := []struct {
+		name, head, sha   string
+		expectedSHAs      []string
+		expectedMinimized int
+	}{
+		{"head commit", "def456", "def456", []string{"abc123", "def456"}, 1},
+		{"previous commit", "def456", "abc123", []string{"abc123"}, 0},
+		{"unknown head", "", "def456", []string{"abc123", "def456"}, 1},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &vcstest.Client{
+				Head:     tc.head,
+				Comments: []vcs.Comment{{URL: "0", Body: comment.NewSummary("abc123").String()}},
+			}
+			s := &Server{vcsClient: client}
+
+			in := &pb.JobFinishedRequest{CommentsUrl: "comments", HeadSha: tc.sha, ProjectDir: "infra"}
+			if err := s.updateSummaryComment(in, "output"); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(client.Comments) != len(tc.expectedSHAs) {
+				t.Fatalf("expected %d comments, got %d", len(tc.expectedSHAs), len(client.Comments))
+			}
+			for i, c := range client.Comments {
+				sum, ok := comment.ParseSummary(c.Body)
+				if !ok || sum.SHA != tc.expectedSHAs[i] {
+					t.Errorf("expected a summary of %s, got %q", tc.expectedSHAs[i], c.Body)
+				}
+			}
+			if len(client.Minimized) != tc.expectedMinimized {
+				t.Errorf("expected %d minimized comments, got %v", tc.expectedMinimized, client.Minimized)
+			}
+		})
+	}
+}
@@ -0,0 +1,274 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// Job is a project's job, started once the jobs it depends on succeed.
+type Job struct {
+	// ID identifies the job when it's reported as finished, see JobID.
+	ID string
+	// Name describes the job in the statuses of its dependents.
+	Name string
+	// DependsOn are the IDs of the jobs that have to succeed before this one
+	// starts.
+	DependsOn []string
+	// Start creates the job.
+	Start func() error
+	// Skip reports that the job won't run, and why.
+	Skip func(reason string) error
+	// PullRequest identifies the pull request of the job, and SHA the commit
+	// it runs on. Waiting jobs are skipped once a job of a newer commit of the
+	// same pull request is scheduled.
+	PullRequest string
+	SHA         string
+}
+
+// Scheduler starts the jobs in the order of their dependencies. It only keeps
+// track of the jobs waiting for others in memory, so turnip runs as a single
+// replica. A job waiting for longer than the timeout is skipped, as its
+// dependencies may never report, e.g. if their runner crashed.
+type Scheduler struct {
+	mu      sync.Mutex
+	timeout time.Duration
+	// waiting holds the jobs with dependencies yet to finish, by ID.
+	waiting map[string]*Job
+	// blockedBy holds the IDs of the unfinished dependencies of a waiting job.
+	blockedBy map[string]map[string]struct{}
+	// dependents holds the IDs of the jobs waiting for a job.
+	dependents map[string][]string
+	// names holds the names of the jobs with dependents.
+	names map[string]string
+	// deadlines holds the timers that skip the waiting jobs, by ID.
+	deadlines map[string]*time.Timer
+}
+
+// New returns an empty Scheduler, that skips the jobs waiting for longer than
+// timeout. A zero timeout waits indefinitely.
+func New(timeout time.Duration) *Scheduler {
+	return &Scheduler{
+		timeout:    timeout,
+		waiting:    make(map[string]*Job),
+		blockedBy:  make(map[string]map[string]struct{}),
+		dependents: make(map[string][]string),
+		names:      make(map[string]string),
+		deadlines:  make(map[string]*time.Timer),
+	}
+}
+
+// JobID returns the ID of a job from its check URL and name.
+func JobID(checkURL, checkName string) string {
+	return fmt.Sprintf("%s/%s", checkURL, checkName)
+}
+
+// Schedule starts the jobs without dependencies, and holds the rest until
+// their dependencies finish. Dependencies on jobs that are not part of jobs
+// are ignored. The jobs still waiting for a previous commit of the same pull
+// request are skipped.
+func (s *Scheduler) Schedule(jobs []*Job) error {
+	names := make(map[string]string, len(jobs))
+	for _, job := range jobs {
+		names[job.ID] = job.Name
+	}
+
+	ready := make([]*Job, 0, len(jobs))
+	s.mu.Lock()
+	superseded := s.supersede(jobs)
+	for _, job := range jobs {
+		deps := make(map[string]struct{})
+		for _, dep := range job.DependsOn {
+			if _, ok := names[dep]; ok && dep != job.ID {
+				deps[dep] = struct{}{}
+			}
+		}
+		if len(deps) == 0 {
+			ready = append(ready, job)
+			continue
+		}
+		s.waiting[job.ID] = job
+		s.blockedBy[job.ID] = deps
+		for dep := range deps {
+			s.dependents[dep] = append(s.dependents[dep], job.ID)
+			s.names[dep] = names[dep]
+		}
+		if s.timeout > 0 {
+			s.deadlines[job.ID] = time.AfterFunc(s.timeout, func() { s.expire(job) })
+		}
+	}
+	s.mu.Unlock()
+
+	skip(superseded)
+
+	var errs []error
+	for _, job := range ready {
+		if err := s.start(job); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Finished starts the dependents of the job that are no longer waiting for
+// others, or skips them if it didn't succeed.
+func (s *Scheduler) Finished(id string, succeeded bool) {
+	if !succeeded {
+		s.skipDependents(id)
+		return
+	}
+
+	s.mu.Lock()
+	ready := make([]*Job, 0)
+	for _, dep := range s.dependents[id] {
+		blocked, ok := s.blockedBy[dep]
+		if !ok {
+			continue
+		}
+		delete(blocked, id)
+		if len(blocked) == 0 {
+			ready = append(ready, s.waiting[dep])
+			s.remove(dep)
+		}
+	}
+	delete(s.dependents, id)
+	delete(s.names, id)
+	s.mu.Unlock()
+
+	for _, job := range ready {
+		if err := s.start(job); err != nil {
+			log.Error("error starting job", "job", job.Name, "error", err)
+		}
+	}
+}
+
+// start starts the job, and if it fails to, reports it and skips its
+// dependents.
+func (s *Scheduler) start(job *Job) error {
+	log.Debug("starting job", "job", job.Name)
+	if err := job.Start(); err != nil {
+		if serr := job.Skip("Failed to start"); serr != nil {
+			log.Error("error skipping job", "job", job.Name, "error", serr)
+		}
+		s.skipDependents(job.ID)
+		return err
+	}
+	return nil
+}
+
+// skipDependents skips the jobs waiting for the failed one, and then the ones
+// waiting for them.
+func (s *Scheduler) skipDependents(id string) {
+	s.mu.Lock()
+	var skipped []skippedJob
+	queue := []string{id}
+	for len(queue) > 0 {
+		failed := queue[0]
+		queue = queue[1:]
+		for _, dep := range s.dependents[failed] {
+			job, ok := s.waiting[dep]
+			if !ok {
+				continue
+			}
+			reason := fmt.Sprintf("Skipped, %s didn't succeed", s.names[failed])
+			skipped = append(skipped, skippedJob{job, reason})
+			queue = append(queue, job.ID)
+			s.remove(dep)
+		}
+		delete(s.dependents, failed)
+		delete(s.names, failed)
+	}
+	s.mu.Unlock()
+
+	skip(skipped)
+}
+
+// supersede removes the waiting jobs of the pull requests of jobs that run on
+// other commits, and returns them. It has to be called with the lock held.
+func (s *Scheduler) supersede(jobs []*Job) []skippedJob {
+	shas := make(map[string]string)
+	for _, job := range jobs {
+		if job.PullRequest != "" {
+			shas[job.PullRequest] = job.SHA
+		}
+	}
+
+	var skipped []skippedJob
+	for id, job := range s.waiting {
+		sha, ok := shas[job.PullRequest]
+		if !ok || job.SHA == sha {
+			continue
+		}
+		skipped = append(skipped, skippedJob{job, "Superseded by " + sha})
+		for dep := range s.blockedBy[id] {
+			s.removeDependent(dep, id)
+		}
+		s.remove(id)
+	}
+	return skipped
+}
+
+// expire skips the job if it's still waiting for its dependencies, and then
+// the jobs waiting for it.
+func (s *Scheduler) expire(job *Job) {
+	s.mu.Lock()
+	if s.waiting[job.ID] != job {
+		s.mu.Unlock()
+		return
+	}
+	pending := make([]string, 0, len(s.blockedBy[job.ID]))
+	for dep := range s.blockedBy[job.ID] {
+		pending = append(pending, s.names[dep])
+		s.removeDependent(dep, job.ID)
+	}
+	sort.Strings(pending)
+	s.remove(job.ID)
+	s.mu.Unlock()
+
+	reason := fmt.Sprintf("Skipped, timed out waiting for %s", strings.Join(pending, ", "))
+	skip([]skippedJob{{job, reason}})
+	s.skipDependents(job.ID)
+}
+
+// remove stops tracking a waiting job. It has to be called with the lock held.
+func (s *Scheduler) remove(id string) {
+	delete(s.waiting, id)
+	delete(s.blockedBy, id)
+	if t, ok := s.deadlines[id]; ok {
+		t.Stop()
+		delete(s.deadlines, id)
+	}
+}
+
+// removeDependent removes the dependent from the jobs waiting for dep, and
+// forgets dep once none is. It has to be called with the lock held.
+func (s *Scheduler) removeDependent(dep, id string) {
+	dependents := slices.DeleteFunc(s.dependents[dep], func(d string) bool { return d == id })
+	if len(dependents) > 0 {
+		s.dependents[dep] = dependents
+		return
+	}
+	delete(s.dependents, dep)
+	delete(s.names, dep)
+}
+
+type skippedJob struct {
+	job    *Job
+	reason string
+}
+
+// skip reports the jobs as skipped.
+func skip(jobs []skippedJob) {
+	for _, sk := range jobs {
+		log.Info("skipping job", "job", sk.job.Name, "reason", sk.reason)
+		if err := sk.job.Skip(sk.reason); err != nil {
+			log.Error("error skipping job", "job", sk.job.Name, "error", err)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package scheduler
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recorder struct {
+	started []string
+	skipped map[string]string
+}
+
+func (r *recorder) job(id string, failStart bool, deps ...string) *Job {
+	return &Job{
+		ID:        id,
+		Name:      id,
+		DependsOn: deps,
+		Start: func() error {
+			if failStart {
+				return errors.New("failed")
+			}
+			r.started = append(r.started, id)
+			return nil
+		},
+		Skip: func(reason string) error {
+			r.skipped[id] = reason
+			return nil
+		},
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	r := &recorder{skipped: make(map[string]string)}
+	s := New(0)
+
+	// network <- cluster <- apps, and dns is independent. The dependency on
+	// a job outside of the batch is ignored.
+	if err := s.Schedule([]*Job{
+		r.job("apps", false, "cluster"),
+		r.job("cluster", false, "network"),
+		r.job("network", false),
+		r.job("dns", false, "unknown"),
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertStarted(t, r, "dns", "network")
+
+	s.Finished("network", true)
+	assertStarted(t, r, "cluster", "dns", "network")
+
+	s.Finished("cluster", true)
+	assertStarted(t, r, "apps", "cluster", "dns", "network")
+	if len(r.skipped) != 0 {
+		t.Errorf("expected no skipped jobs, got %v", r.skipped)
+	}
+}
+
+func TestScheduleSkipsDependents(t *testing.T) {
+	r := &recorder{skipped: make(map[string]string)}
+	s := New(0)
+
+	if err := s.Schedule([]*Job{
+		r.job("apps", false, "cluster", "dns"),
+		r.job("cluster", false, "network"),
+		r.job("network", false),
+		r.job("dns", false),
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s.Finished("dns", true)
+	s.Finished("network", false)
+	assertStarted(t, r, "dns", "network")
+
+	expected := map[string]string{
+		"cluster": "Skipped, network didn't succeed",
+		"apps":    "Skipped, cluster didn't succeed",
+	}
+	if !reflect.DeepEqual(r.skipped, expected) {
+		t.Errorf("expected %v, got %v", expected, r.skipped)
+	}
+
+	// Reporting the skipped jobs as finished is a no-op.
+	s.Finished("cluster", true)
+	assertStarted(t, r, "dns", "network")
+}
+
+func TestScheduleFailedStart(t *testing.T) {
+	r := &recorder{skipped: make(map[string]string)}
+	s := New(0)
+
+	err := s.Schedule([]*Job{
+		r.job("cluster", false, "network"),
+		r.job("network", true),
+	})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	expected := map[string]string{
+		"network": "Failed to start",
+		"cluster": "Skipped, network didn't succeed",
+	}
+	if !reflect.DeepEqual(r.skipped, expected) {
+		t.Errorf("expected %v, got %v", expected, r.skipped)
+	}
+}
+
+func TestScheduleSupersedes(t *testing.T) {
+	r := &recorder{skipped: make(map[string]string)}
+	s := New(0)
+
+	batch := func(sha string, jobs ...*Job) []*Job {
+		for _, job := range jobs {
+			job.PullRequest, job.SHA = "pr/1", sha
+		}
+		return jobs
+	}
+	if err := s.Schedule(batch("abc123",
+		r.job("cluster", false, "network"),
+		r.job("network", false),
+	)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	other := r.job("other", false, "network")
+	other.PullRequest, other.SHA = "pr/2", "abc123"
+	if err := s.Schedule([]*Job{other, r.job("network", false)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A command on the same commit doesn't skip the waiting jobs.
+	if err := s.Schedule(batch("abc123", r.job("dns", false))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(r.skipped) != 0 {
+		t.Errorf("expected no skipped jobs, got %v", r.skipped)
+	}
+
+	if err := s.Schedule(batch("def456", r.job("apps", false))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]string{"cluster": "Superseded by def456"}
+	if !reflect.DeepEqual(r.skipped, expected) {
+		t.Errorf("expected %v, got %v", expected, r.skipped)
+	}
+
+	s.Finished("network", true)
+	assertStarted(t, r, "apps", "dns", "network", "network", "other")
+}
+
+func assertStarted(t *testing.T, r *recorder, expected ...string) {
+	t.Helper()
+	started := append([]string{}, r.started...)
+	sort.Strings(started)
+	if !reflect.DeepEqual(started, expected) {
+		t.Errorf("expected %v to be started, got %v", expected, started)
+	}
+}
+
+func TestScheduleTimesOut(t *testing.T) {
+	var mu sync.Mutex
+	skipped := make(map[string]string)
+	done := make(chan struct{})
+	job := func(id string, deps ...string) *Job {
+		return &Job{
+			ID:        id,
+			Name:      id,
+			DependsOn: deps,
+			Start:     func() error { return nil },
+			Skip: func(reason string) error {
+				mu.Lock()
+				defer mu.Unlock()
+				skipped[id] = reason
+				close(done)
+				return nil
+			},
+		}
+	}
+	s := New(10 * time.Millisecond)
+
+	// network never reports, so cluster is skipped.
+	if err := s.Schedule([]*Job{
+		job("cluster", "dns", "network"),
+		job("dns"),
+		job("network"),
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s.Finished("dns", true)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the waiting jobs to be skipped")
+	}
+
+	mu.Lock()
+	expected := map[string]string{"cluster": "Skipped, timed out waiting for network"}
+	if !reflect.DeepEqual(skipped, expected) {
+		t.Errorf("expected %v, got %v", expected, skipped)
+	}
+	mu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.waiting) != 0 || len(s.blockedBy) != 0 || len(s.dependents) != 0 || len(s.names) != 0 || len(s.deadlines) != 0 {
+		t.Errorf("expected no tracked jobs, got %v, %v, %v, %v, %v", s.waiting, s.blockedBy, s.dependents, s.names, s.deadlines)
+	}
+}
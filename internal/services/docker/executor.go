@@ -0,0 +1,108 @@
+package docker
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/ivanvc/turnip/internal/config"
+	"github.com/ivanvc/turnip/internal/services/executor"
+	"github.com/ivanvc/turnip/internal/yaml"
+)
+
+// Executor runs the runner in a Docker container, through the docker CLI.
+type Executor struct {
+	image          string
+	network        string
+	serverName     string
+	githubToken    string
+	gitlabURL      string
+	gitlabToken    string
+	jobEnvFile     string
+	redactPatterns []string
+}
+
+// NewExecutor returns an executor running the runner image.
+func NewExecutor(config *config.Config) *Executor {
+	return &Executor{
+		image:          config.RunnerImage,
+		network:        config.DockerNetwork,
+		serverName:     config.ServerName,
+		githubToken:    config.GitHubToken,
+		gitlabURL:      config.GitLabURL,
+		gitlabToken:    config.GitLabToken,
+		jobEnvFile:     config.JobEnvFile,
+		redactPatterns: config.RedactPatterns,
+	}
+}
+
+// CreateJob starts a detached container, removed once the runner exits. It
+// conforms to the executor.Executor interface.
+func (e *Executor) CreateJob(command, cloneURL, headRef, headSHA, repoFullName, checkURL, checkName, commentsURL, extraArgs string, project *yaml.Project) error {
+	secrets := make([]string, 0)
+	if e.jobEnvFile != "" {
+		var err error
+		if secrets, err = executor.LoadEnvFile(e.jobEnvFile); err != nil {
+			log.Error("error loading job env file", "error", err)
+			return err
+		}
+	}
+
+	job := executor.Job{
+		Command:        command,
+		CloneURL:       cloneURL,
+		HeadRef:        headRef,
+		HeadSHA:        headSHA,
+		RepoFullName:   repoFullName,
+		CheckURL:       checkURL,
+		CheckName:      checkName,
+		CommentsURL:    commentsURL,
+		ExtraArgs:      extraArgs,
+		Project:        project,
+		ServerName:     e.serverName,
+		SecretEnvNames: executor.EnvNames(secrets),
+		RedactPatterns: e.redactPatterns,
+	}
+	env := append([]string{
+		"TURNIP_GITHUB_TOKEN=" + e.githubToken,
+		"TURNIP_GITLAB_URL=" + e.gitlabURL,
+		"TURNIP_GITLAB_TOKEN=" + e.gitlabToken,
+	}, secrets...)
+	env = append(env, job.Env()...)
+
+	cmd := exec.Command("docker", runArgs(e.image, e.network, job, env)...)
+	// The values are read from docker's environment, so they aren't part of
+	// its command line.
+	cmd.Env = append(os.Environ(), env...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	log.Info("starting runner container", "command", command, "project", project.DisplayName(), "image", e.image)
+	if err := cmd.Run(); err != nil {
+		log.Error("error starting runner container", "error", err, "stderr", stderr.String())
+		return fmt.Errorf("docker run: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// runArgs returns the arguments of docker run, passing the variables of env
+// by name.
+func runArgs(image, network string, job executor.Job, env []string) []string {
+	args := []string{
+		"run", "--rm", "--detach",
+		"--label", "app=turnip",
+		"--label", "turnip.ivan.vc/repo=" + job.RepoFullName,
+		"--label", "turnip.ivan.vc/command=" + job.Command,
+	}
+	if network != "" {
+		args = append(args, "--network", network)
+	}
+	for _, name := range executor.EnvNames(env) {
+		args = append(args, "--env", name)
+	}
+	return append(args, image, "/opt/turnip/bin/xl-15")
+}
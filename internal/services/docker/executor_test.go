@@ -0,0 +1,28 @@
+package docker
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ivanvc/turnip/internal/services/executor"
+)
+
+func TestRunArgs(t *testing.T) {
+	job := executor.Job{Command: "plot", RepoFullName: "o/r"}
+	args := runArgs("ivan/turnip:latest", "turnip", job, []string{"TURNIP_GITHUB_TOKEN=ghp_token", "TURNIP_COMMAND=plot"})
+
+	if strings.Contains(strings.Join(args, " "), "ghp_token") {
+		t.Errorf("expected the values not to be in the arguments, got %v", args)
+	}
+	for _, expected := range [][]string{
+		{"--env", "TURNIP_GITHUB_TOKEN"},
+		{"--network", "turnip"},
+		{"ivan/turnip:latest", "/opt/turnip/bin/xl-15"},
+	} {
+		i := slices.Index(args, expected[0])
+		if i < 0 || i+1 >= len(args) || args[i+1] != expected[1] {
+			t.Errorf("expected %v in %v", expected, args)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package executor
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/ivanvc/turnip/internal/yaml"
+)
+
+// Executor runs the runner, xl-15, for a job. It returns once the job is
+// started, the runner reports its result through RPC.
+type Executor interface {
+	CreateJob(command, cloneURL, headRef, headSHA, repoFullName, checkURL, checkName, commentsURL, extraArgs string, project *yaml.Project) error
+}
+
+// Job holds the values of a job that are passed to the runner.
+type Job struct {
+	Command      string
+	CloneURL     string
+	HeadRef      string
+	HeadSHA      string
+	RepoFullName string
+	CheckURL     string
+	CheckName    string
+	CommentsURL  string
+	ExtraArgs    string
+	Project      *yaml.Project
+	// ServerName is the host of the RPC server.
+	ServerName string
+	// SecretEnvNames are the names of the secret variables in the runner's
+	// environment, so it redacts their values.
+	SecretEnvNames []string
+	RedactPatterns []string
+}
+
+// Env returns the runner's environment for the job, as KEY=value. The
+// runner's variables follow the workflow's and then the project's env, so the
+// repository can't override them, e.g. to turn off the redaction.
+func (j Job) Env() []string {
+	projectYAML, err := j.Project.ToYAML()
+	if err != nil {
+		log.Error("error marshaling project YAML", "error", err)
+	}
+	patterns, err := json.Marshal(j.RedactPatterns)
+	if err != nil {
+		log.Error("error marshaling redact patterns", "error", err)
+		patterns = []byte("[]")
+	}
+
+	env := j.Project.LoadedWorkflow.GetEnv()
+	env = append(env, j.Project.GetEnv()...)
+	return append(env,
+		"TURNIP_CLONE_URL="+j.CloneURL,
+		"TURNIP_HEAD_REF="+j.HeadRef,
+		"TURNIP_HEAD_SHA="+j.HeadSHA,
+		"TURNIP_COMMAND="+j.Command,
+		"TURNIP_CHECK_URL="+j.CheckURL,
+		"TURNIP_CHECK_NAME="+j.CheckName,
+		"TURNIP_PROJECT_YAML="+string(projectYAML),
+		"TURNIP_SERVER_NAME="+j.ServerName,
+		"TURNIP_COMMENTS_URL="+j.CommentsURL,
+		"TURNIP_EXTRA_ARGS="+j.ExtraArgs,
+		"TURNIP_SECRET_ENV_NAMES="+strings.Join(j.SecretEnvNames, ","),
+		"TURNIP_REDACT_PATTERNS="+string(patterns),
+	)
+}
+
+// LoadEnvFile reads the KEY=value lines of the file, skipping the empty ones
+// and the comments. It returns the variables sorted by name.
+func LoadEnvFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	env := make([]string, 0)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if k, _, ok := strings.Cut(line, "="); !ok || k == "" {
+			return nil, fmt.Errorf("%s:%d: expected KEY=value", path, n)
+		}
+		env = append(env, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	sort.Strings(env)
+	return env, nil
+}
+
+// EnvNames returns the names of the KEY=value variables.
+func EnvNames(env []string) []string {
+	names := make([]string, len(env))
+	for i, v := range env {
+		names[i], _, _ = strings.Cut(v, "=")
+	}
+	return names
+}
@@ -0,0 +1,67 @@
+package executor
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ivanvc/turnip/internal/yaml"
+)
+
+func TestLoadEnvFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "job.env")
+	data := "# The job secrets.\nAWS_SECRET_ACCESS_KEY=abc=\n\nAWS_ACCESS_KEY_ID=AKIA\n"
+	if err := os.WriteFile(file, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	env, err := LoadEnvFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"AWS_ACCESS_KEY_ID=AKIA", "AWS_SECRET_ACCESS_KEY=abc="}
+	if !slices.Equal(env, expected) {
+		t.Errorf("expected %v, got %v", expected, env)
+	}
+	if names := EnvNames(env); !slices.Equal(names, []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY"}) {
+		t.Errorf("unexpected names %v", names)
+	}
+
+	if err := os.WriteFile(file, []byte("=value\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadEnvFile(file); err == nil {
+		t.Error("expected an error for a variable without name")
+	}
+}
+
+func TestJobEnvCannotBeOverridden(t *testing.T) {
+	job := Job{
+		SecretEnvNames: []string{"AWS_SECRET_ACCESS_KEY"},
+		Project: &yaml.Project{
+			Env: map[string]string{"TURNIP_SECRET_ENV_NAMES": "", "TF_LOG": "info"},
+			LoadedWorkflow: yaml.Workflow{
+				Env: map[string]string{"TURNIP_REDACT_PATTERNS": "[]"},
+			},
+		},
+		RedactPatterns: []string{"password=(\\S+)"},
+	}
+
+	// The last value of a variable is the one the runner gets.
+	env := make(map[string]string)
+	for _, v := range job.Env() {
+		k, val, _ := strings.Cut(v, "=")
+		env[k] = val
+	}
+	if env["TURNIP_SECRET_ENV_NAMES"] != "AWS_SECRET_ACCESS_KEY" {
+		t.Errorf("expected the project env not to override the secret names, got %q", env["TURNIP_SECRET_ENV_NAMES"])
+	}
+	if env["TURNIP_REDACT_PATTERNS"] != `["password=(\\S+)"]` {
+		t.Errorf("expected the workflow env not to override the redact patterns, got %q", env["TURNIP_REDACT_PATTERNS"])
+	}
+	if env["TF_LOG"] != "info" {
+		t.Errorf("expected the project env to be set, got %q", env["TF_LOG"])
+	}
+}
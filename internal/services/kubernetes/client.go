@@ -3,6 +3,7 @@ package kubernetes
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/charmbracelet/log"
@@ -14,6 +15,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 
 	"github.com/ivanvc/turnip/internal/config"
+	"github.com/ivanvc/turnip/internal/services/executor"
 	"github.com/ivanvc/turnip/internal/yaml"
 )
 
@@ -26,39 +28,58 @@ type Client struct {
 	*k8s.Clientset
 	config         *rest.Config
 	namespace      string
-	githubToken    string
 	serverName     string
 	jobSecrets     string
 	jobTTLSeconds  int
 	podAnnotations map[string]string
+	redactPatterns []string
 }
 
 // LoadClient creates a new Client singleton.
-func LoadClient(config *config.Config) *Client {
+func LoadClient(config *config.Config) (*Client, error) {
 	cfg, err := ctrl.GetConfig()
 	if err != nil {
-		log.Fatal("error loading kubeconfig", "error", err)
+		log.Error("error loading kubeconfig", "error", err)
+		return nil, err
 	}
 	cs, err := k8s.NewForConfig(cfg)
 	if err != nil {
-		log.Fatal("error initializing Kubernetes client", "error", err)
+		log.Error("error initializing Kubernetes client", "error", err)
+		return nil, err
 	}
 	return &Client{
 		Clientset:      cs,
 		config:         cfg,
 		namespace:      config.Namespace,
-		githubToken:    config.GitHubToken,
 		serverName:     config.ServerName,
 		jobSecrets:     config.JobSecretsName,
 		jobTTLSeconds:  config.JobTTLSecondsAfterFinished,
 		podAnnotations: config.RunnerPodAnnotations,
-	}
+		redactPatterns: config.RedactPatterns,
+	}, nil
 }
 
-func (c *Client) CreateJob(command, cloneURL, headRef, repoFullName, checkURL, checkName, commentsURL, extraArgs string, project *yaml.Project) error {
+// CreateJob creates a Kubernetes Job running the runner. It conforms to the
+// executor.Executor interface.
+func (c *Client) CreateJob(command, cloneURL, headRef, headSHA, repoFullName, checkURL, checkName, commentsURL, extraArgs string, project *yaml.Project) error {
+	job := executor.Job{
+		Command:        command,
+		CloneURL:       cloneURL,
+		HeadRef:        headRef,
+		HeadSHA:        headSHA,
+		RepoFullName:   repoFullName,
+		CheckURL:       checkURL,
+		CheckName:      checkName,
+		CommentsURL:    commentsURL,
+		ExtraArgs:      extraArgs,
+		Project:        project,
+		ServerName:     c.serverName,
+		SecretEnvNames: c.getSecretEnvNames(),
+		RedactPatterns: c.redactPatterns,
+	}
 	if _, err := c.BatchV1().Jobs(c.namespace).Create(
 		context.Background(),
-		getJob(c.namespace, c.githubToken, c.serverName, c.jobSecrets, command, cloneURL, headRef, repoFullName, checkURL, checkName, commentsURL, extraArgs, project, c.jobTTLSeconds, c.podAnnotations),
+		getJob(c.namespace, c.jobSecrets, job, c.jobTTLSeconds, c.podAnnotations),
 		metav1.CreateOptions{},
 	); err != nil {
 		return err
@@ -67,62 +88,36 @@ func (c *Client) CreateJob(command, cloneURL, headRef, repoFullName, checkURL, c
 	return nil
 }
 
-func getJob(namespace, token, serverName, jobSecrets, command, cloneURL, headRef, repoFullName, checkURL, checkName, commentsURL, extraArgs string, project *yaml.Project, jobTTLSeconds int, annotations map[string]string) *batchv1.Job {
-	projectYAML := marshalProjectYAML(project)
-	generatedName := getGeneratedName(command, repoFullName, project)
-	ttlSeconds := int32(jobTTLSeconds)
-
-	podAnnotations := getPodAnotations(project, annotations)
-	env := []corev1.EnvVar{
-		{
-			Name:  "TURNIP_CLONE_URL",
-			Value: cloneURL,
-		},
-		{
-			Name:  "TURNIP_HEAD_REF",
-			Value: headRef,
-		},
-		{
-			Name:  "TURNIP_COMMAND",
-			Value: command,
-		},
-		{
-			Name:  "TURNIP_CHECK_URL",
-			Value: checkURL,
-		},
-		{
-			Name:  "TURNIP_CHECK_NAME",
-			Value: checkName,
-		},
-		{
-			Name:  "TURNIP_PROJECT_YAML",
-			Value: string(projectYAML),
-		},
-		{
-			Name:  "TURNIP_SERVER_NAME",
-			Value: serverName,
-		},
-		{
-			Name:  "TURNIP_COMMENTS_URL",
-			Value: commentsURL,
-		},
-		{
-			Name:  "TURNIP_EXTRA_ARGS",
-			Value: extraArgs,
-		},
+// getSecretEnvNames returns the keys of the job secrets, which are exposed as
+// environment variables to the runner, so it can redact their values.
+func (c *Client) getSecretEnvNames() []string {
+	secret, err := c.CoreV1().Secrets(c.namespace).Get(context.Background(), c.jobSecrets, metav1.GetOptions{})
+	if err != nil {
+		log.Error("error getting job secrets, their values won't be redacted", "error", err, "secret", c.jobSecrets)
+		return []string{}
 	}
 
-	for k, v := range project.LoadedWorkflow.Env {
-		env = append(env, corev1.EnvVar{
-			Name:  k,
-			Value: v,
-		})
+	names := make([]string, 0, len(secret.Data)+len(secret.StringData))
+	for k := range secret.Data {
+		names = append(names, k)
 	}
-	for k, v := range project.Env {
-		env = append(env, corev1.EnvVar{
-			Name:  k,
-			Value: v,
-		})
+	for k := range secret.StringData {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func getJob(namespace, jobSecrets string, job executor.Job, jobTTLSeconds int, annotations map[string]string) *batchv1.Job {
+	command, repoFullName := job.Command, job.RepoFullName
+	generatedName := getGeneratedName(command, repoFullName, job.Project)
+	ttlSeconds := int32(jobTTLSeconds)
+
+	podAnnotations := getPodAnotations(job.Project, annotations)
+	env := make([]corev1.EnvVar, 0)
+	for _, v := range job.Env() {
+		name, value, _ := strings.Cut(v, "=")
+		env = append(env, corev1.EnvVar{Name: name, Value: value})
 	}
 
 	// TODO: Use workflow.image, add an init container that downloads the turnip binary
@@ -187,14 +182,6 @@ func getGeneratedName(command, repoFullName string, project *yaml.Project) strin
 	return nameTpl
 }
 
-func marshalProjectYAML(project *yaml.Project) []byte {
-	result, err := project.ToYAML()
-	if err != nil {
-		log.Error("error marshaling project YAML", "error", err)
-	}
-	return result
-}
-
 func getPodAnotations(project *yaml.Project, configAnnotations map[string]string) map[string]string {
 	annotations := make(map[string]string)
 	for k, v := range configAnnotations {
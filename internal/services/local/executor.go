@@ -0,0 +1,105 @@
+package local
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/ivanvc/turnip/internal/config"
+	"github.com/ivanvc/turnip/internal/services/executor"
+	"github.com/ivanvc/turnip/internal/yaml"
+)
+
+// Executor runs the runner as a subprocess of the server. Each job installs
+// its tools to its own temporary directory.
+type Executor struct {
+	runnerPath     string
+	githubToken    string
+	gitlabURL      string
+	gitlabToken    string
+	jobEnvFile     string
+	redactPatterns []string
+}
+
+// NewExecutor returns an executor running the runner binary at the
+// configured path, or found in the PATH.
+func NewExecutor(config *config.Config) *Executor {
+	return &Executor{
+		runnerPath:     config.RunnerPath,
+		githubToken:    config.GitHubToken,
+		gitlabURL:      config.GitLabURL,
+		gitlabToken:    config.GitLabToken,
+		jobEnvFile:     config.JobEnvFile,
+		redactPatterns: config.RedactPatterns,
+	}
+}
+
+// CreateJob starts the runner, without waiting for it to finish. It conforms
+// to the executor.Executor interface.
+func (e *Executor) CreateJob(command, cloneURL, headRef, headSHA, repoFullName, checkURL, checkName, commentsURL, extraArgs string, project *yaml.Project) error {
+	secrets := make([]string, 0)
+	if e.jobEnvFile != "" {
+		var err error
+		if secrets, err = executor.LoadEnvFile(e.jobEnvFile); err != nil {
+			log.Error("error loading job env file", "error", err)
+			return err
+		}
+	}
+
+	tmpDir, err := os.MkdirTemp("", "turnip-job-*")
+	if err != nil {
+		log.Error("error creating temp dir", "error", err)
+		return err
+	}
+	binDir := filepath.Join(tmpDir, "bin")
+
+	job := executor.Job{
+		Command:        command,
+		CloneURL:       cloneURL,
+		HeadRef:        headRef,
+		HeadSHA:        headSHA,
+		RepoFullName:   repoFullName,
+		CheckURL:       checkURL,
+		CheckName:      checkName,
+		CommentsURL:    commentsURL,
+		ExtraArgs:      extraArgs,
+		Project:        project,
+		ServerName:     "localhost",
+		SecretEnvNames: executor.EnvNames(secrets),
+		RedactPatterns: e.redactPatterns,
+	}
+
+	cmd := exec.Command(e.runnerPath)
+	cmd.Dir = tmpDir
+	// The runner runs the repository's code, so it doesn't inherit the
+	// server's environment, only the job's.
+	cmd.Env = []string{
+		"HOME=" + tmpDir,
+		"TURNIP_GITHUB_TOKEN=" + e.githubToken,
+		"TURNIP_GITLAB_URL=" + e.gitlabURL,
+		"TURNIP_GITLAB_TOKEN=" + e.gitlabToken,
+		"TURNIP_BIN_DIR=" + binDir,
+		"PATH=" + binDir + string(os.PathListSeparator) + os.Getenv("PATH"),
+		"TMPDIR=" + tmpDir,
+	}
+	cmd.Env = append(cmd.Env, secrets...)
+	cmd.Env = append(cmd.Env, job.Env()...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	log.Info("starting runner", "command", command, "project", project.DisplayName(), "dir", tmpDir)
+	if err := cmd.Start(); err != nil {
+		os.RemoveAll(tmpDir)
+		log.Error("error starting runner", "error", err)
+		return err
+	}
+	go func() {
+		defer os.RemoveAll(tmpDir)
+		if err := cmd.Wait(); err != nil {
+			log.Error("runner exited with an error", "command", command, "project", project.DisplayName(), "error", err)
+		}
+	}()
+	return nil
+}
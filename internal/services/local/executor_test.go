@@ -0,0 +1,62 @@
+package local
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivanvc/turnip/internal/yaml"
+)
+
+func TestCreateJob(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "env")
+	runner := filepath.Join(dir, "runner")
+	script := "#!/bin/sh\nenv > " + out + ".tmp && mv " + out + ".tmp " + out + "\n"
+	if err := os.WriteFile(runner, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	envFile := filepath.Join(dir, "job.env")
+	if err := os.WriteFile(envFile, []byte("CLOUD_TOKEN=secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("TURNIP_API_TOKEN", "server-secret")
+	e := &Executor{runnerPath: runner, githubToken: "ghp_token", jobEnvFile: envFile}
+	project := &yaml.Project{
+		Dir:            "infra",
+		Env:            map[string]string{"TF_LOG": "debug"},
+		Workflow:       "custom",
+		LoadedWorkflow: yaml.Workflow{Custom: &yaml.CustomAdapter{}},
+	}
+	if err := e.CreateJob("plot", "https://github.com/o/r.git", "main", "abc", "o/r", "check", "turnip/custom/plot/infra/", "comments", "", project); err != nil {
+		t.Fatal(err)
+	}
+
+	var env []byte
+	for i := 0; i < 50; i++ {
+		var err error
+		if env, err = os.ReadFile(out); err == nil {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	for _, v := range []string{
+		"TURNIP_COMMAND=plot",
+		"TURNIP_SERVER_NAME=localhost",
+		"TURNIP_GITHUB_TOKEN=ghp_token",
+		"TURNIP_SECRET_ENV_NAMES=CLOUD_TOKEN",
+		"CLOUD_TOKEN=secret",
+		"TF_LOG=debug",
+		"TURNIP_BIN_DIR=",
+	} {
+		if !strings.Contains(string(env), v) {
+			t.Errorf("expected %s in the runner's environment, got:\n%s", v, env)
+		}
+	}
+	if strings.Contains(string(env), "TURNIP_API_TOKEN") {
+		t.Errorf("expected the server's environment not to be passed to the runner, got:\n%s", env)
+	}
+}
@@ -2,37 +2,64 @@ package template
 
 import (
 	"bytes"
-	"html/template"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"text/template"
+	"text/template/parse"
 
 	sprig "github.com/go-task/slim-sprig"
 
 	"github.com/ivanvc/turnip/internal/yaml"
 )
 
+// Environment holds the values available to the templates.
 type Environment struct {
 	Stack       string
 	Workspace   string
 	Environment string
+	ProjectName string
 	ProjectDir  string
+
+	// Repo is the full name of the repository, i.e. owner/name.
+	Repo string
+	// PullRequest is the number of the pull request, or 0 if the command
+	// wasn't triggered from one.
+	PullRequest int
+	HeadSHA     string
+	BaseRef     string
+	// Commenter is the login of the user that triggered the command.
+	Commenter string
+	// Command is the command verb, either plot or lift.
+	Command string
 }
 
 type Template struct {
 	Environment
 }
 
-func New(project yaml.Project) Template {
-	return Template{
-		Environment: Environment{
-			Stack:       project.Stack,
-			Workspace:   project.Workspace,
-			Environment: project.Environment,
-			ProjectDir:  project.Dir,
-		},
+// New returns a template for the project. The project values of env are
+// overwritten with the ones from the project.
+func New(project yaml.Project, env Environment) Template {
+	env.Stack = project.Stack
+	env.Workspace = project.GetWorkspace()
+	if env.Workspace == "" {
+		env.Workspace = project.Workspace
 	}
+	env.Environment = project.Environment
+	env.ProjectName = project.Name
+	env.ProjectDir = project.Dir
+	return Template{Environment: env}
 }
 
+// Execute renders the input. Only the hermetic functions are available, so
+// templates can't read the server's environment, e.g. its tokens.
 func (t Template) Execute(input string) (string, error) {
-	tpl, err := template.New("template").Funcs(sprig.TxtFuncMap()).Parse(input)
+	if !strings.Contains(input, "{{") {
+		return input, nil
+	}
+	tpl, err := template.New("template").Funcs(sprig.HermeticTxtFuncMap()).Parse(input)
 	if err != nil {
 		return "", err
 	}
@@ -42,3 +69,166 @@ func (t Template) Execute(input string) (string, error) {
 	}
 	return buf.String(), nil
 }
+
+// maxArgsSize is the maximum size of the rendered extra args.
+const maxArgsSize = 4096
+
+// argsFuncs are the only functions available to the extra args. They replace
+// the builtin ones too, e.g. printf, which could render unbounded output.
+var argsFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"trim":  strings.TrimSpace,
+}
+
+// ExecuteArgs renders the extra args of a command. They may come from a
+// comment, so only the actions printing the environment's fields, optionally
+// piped to argsFuncs, are allowed, e.g. {{ .Workspace | upper }}, and the
+// output is limited to maxArgsSize.
+func (t Template) ExecuteArgs(input string) (string, error) {
+	if !strings.Contains(input, "{{") {
+		return input, nil
+	}
+	if len(input) > maxArgsSize {
+		return "", fmt.Errorf("extra args are longer than %d bytes", maxArgsSize)
+	}
+	tpl, err := template.New("args").Funcs(argsFuncs).Parse(input)
+	if err != nil {
+		return "", err
+	}
+	if err := checkArgsNodes(tpl.Tree.Root); err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, t.Environment); err != nil {
+		return "", err
+	}
+	if buf.Len() > maxArgsSize {
+		return "", fmt.Errorf("rendered extra args are longer than %d bytes", maxArgsSize)
+	}
+	return buf.String(), nil
+}
+
+// checkArgsNodes returns an error if the template has anything but text and
+// actions printing a field, optionally piped to the argsFuncs.
+func checkArgsNodes(list *parse.ListNode) error {
+	for _, node := range list.Nodes {
+		switch n := node.(type) {
+		case *parse.TextNode:
+		case *parse.ActionNode:
+			if len(n.Pipe.Decl) > 0 || len(n.Pipe.Cmds) == 0 {
+				return fmt.Errorf("unsupported action %s in extra args", n)
+			}
+			for i, cmd := range n.Pipe.Cmds {
+				if err := checkArgsCommand(cmd, i == 0); err != nil {
+					return fmt.Errorf("unsupported action %s in extra args: %w", n, err)
+				}
+			}
+		default:
+			return fmt.Errorf("unsupported action %s in extra args", n)
+		}
+	}
+	return nil
+}
+
+// checkArgsCommand checks a command of a pipeline. The first one has to be a
+// field, and the rest one of the argsFuncs, without arguments.
+func checkArgsCommand(cmd *parse.CommandNode, first bool) error {
+	if len(cmd.Args) != 1 {
+		return fmt.Errorf("functions can't take arguments")
+	}
+	switch arg := cmd.Args[0].(type) {
+	case *parse.FieldNode:
+		if first {
+			return nil
+		}
+	case *parse.IdentifierNode:
+		if _, ok := argsFuncs[arg.Ident]; ok && !first {
+			return nil
+		}
+	}
+	return fmt.Errorf("only fields, optionally piped to upper, lower or trim, are allowed")
+}
+
+// Render returns a copy of the project with its commands, env and pod
+// annotations rendered, both the project's and its workflow's.
+func (t Template) Render(project yaml.Project) (yaml.Project, error) {
+	var err error
+	if project.Env, err = t.renderMap(project.Env); err != nil {
+		return project, fmt.Errorf("env: %w", err)
+	}
+	if project.PodAnnotations, err = t.renderMap(project.PodAnnotations); err != nil {
+		return project, fmt.Errorf("podAnnotations: %w", err)
+	}
+
+	w := &project.LoadedWorkflow
+	if w.Env, err = t.renderMap(w.Env); err != nil {
+		return project, fmt.Errorf("workflow env: %w", err)
+	}
+	if w.PodAnnotations, err = t.renderMap(w.PodAnnotations); err != nil {
+		return project, fmt.Errorf("workflow podAnnotations: %w", err)
+	}
+	for name, cmds := range map[string]*[]yaml.Command{
+		"initCommands": &w.InitCommands,
+		"prePlot":      &w.PrePlot,
+		"postPlot":     &w.PostPlot,
+		"preLift":      &w.PreLift,
+		"postLift":     &w.PostLift,
+	} {
+		if *cmds, err = t.renderCommands(*cmds); err != nil {
+			return project, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	if w.Custom != nil {
+		custom := *w.Custom
+		for name, cmds := range map[string]*[]yaml.Command{
+			"install": &custom.Install,
+			"plot":    &custom.Plot,
+			"lift":    &custom.Lift,
+		} {
+			if *cmds, err = t.renderCommands(*cmds); err != nil {
+				return project, fmt.Errorf("custom %s: %w", name, err)
+			}
+		}
+		w.Custom = &custom
+	}
+
+	return project, nil
+}
+
+func (t Template) renderCommands(cmds []yaml.Command) ([]yaml.Command, error) {
+	if cmds == nil {
+		return nil, nil
+	}
+	out := slices.Clone(cmds)
+	var err error
+	for i := range out {
+		if out[i].Run, err = t.Execute(out[i].Run); err != nil {
+			return nil, err
+		}
+		if out[i].Pulumi, err = t.Execute(out[i].Pulumi); err != nil {
+			return nil, err
+		}
+		if out[i].Env, err = t.renderMap(out[i].Env); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// renderMap returns a copy of the map with its values rendered.
+func (t Template) renderMap(m map[string]string) (map[string]string, error) {
+	if m == nil {
+		return nil, nil
+	}
+	out := maps.Clone(m)
+	for k, v := range out {
+		rendered, err := t.Execute(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", k, err)
+		}
+		out[k] = rendered
+	}
+	return out, nil
+}
@@ -0,0 +1,130 @@
+package template
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ivanvc/turnip/internal/yaml"
+)
+
+func TestExecute(t *testing.T) {
+	tpl := New(yaml.Project{Dir: "infra", Workspace: "prod"}, Environment{Repo: "ivanvc/turnip", PullRequest: 42, Command: "plot"})
+
+	tt := []struct {
+		input    string
+		expected string
+	}{
+		{"-backend-config={{ .Workspace }}.hcl", "-backend-config=prod.hcl"},
+		{"{{ .Repo }}#{{ .PullRequest }} {{ .Command }} {{ .ProjectDir }}", "ivanvc/turnip#42 plot infra"},
+		{"{{ .Workspace | upper }}", "PROD"},
+		{"<b>&</b>", "<b>&</b>"},
+		{"{{ \"<b>&</b>\" }}", "<b>&</b>"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.input, func(t *testing.T) {
+			actual, err := tpl.Execute(tc.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestExecuteIsHermetic(t *testing.T) {
+	t.Setenv("TURNIP_API_TOKEN", "s3cret")
+	tpl := New(yaml.Project{Dir: "infra"}, Environment{})
+
+	for _, input := range []string{`{{ env "TURNIP_API_TOKEN" }}`, `{{ expandenv "$TURNIP_API_TOKEN" }}`, `{{ getHostByName "example.com" }}`} {
+		t.Run(input, func(t *testing.T) {
+			if out, err := tpl.Execute(input); err == nil {
+				t.Errorf("expected an error, got %q", out)
+			}
+		})
+	}
+}
+
+func TestExecuteArgs(t *testing.T) {
+	tpl := New(yaml.Project{Dir: "infra", Workspace: "prod"}, Environment{PullRequest: 42})
+
+	tt := []struct {
+		input    string
+		expected string
+	}{
+		{"-target=module.app", "-target=module.app"},
+		{"-var-file={{ .Workspace }}.tfvars -var pr={{ .PullRequest }}", "-var-file=prod.tfvars -var pr=42"},
+		{"{{ .Workspace | upper | lower }}", "prod"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.input, func(t *testing.T) {
+			actual, err := tpl.ExecuteArgs(tc.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestExecuteArgsIsRestricted(t *testing.T) {
+	tpl := New(yaml.Project{Dir: "infra", Workspace: strings.Repeat("w", maxArgsSize/2)}, Environment{})
+
+	for _, input := range []string{
+		`{{ repeat 1000000000 "a" }}`,
+		`{{ printf "%999999999d" 1 }}`,
+		`{{ range 1000000000 }}a{{ end }}`,
+		`{{ if true }}a{{ end }}`,
+		`{{ $a := .Workspace }}`,
+		`{{ "a" }}`,
+		`{{ upper }}`,
+		`{{ .Workspace | printf "%s" }}`,
+		`{{ .Workspace }}{{ .Workspace }}{{ .Workspace }}`,
+		"{{ .Workspace }}" + strings.Repeat(" ", maxArgsSize),
+	} {
+		t.Run(input[:min(len(input), 50)], func(t *testing.T) {
+			if out, err := tpl.ExecuteArgs(input); err == nil {
+				t.Errorf("expected an error, got %d bytes", len(out))
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	workflow := yaml.Workflow{
+		Env:          map[string]string{"TF_CLI_ARGS_init": "-backend-config={{ .Workspace }}.hcl"},
+		InitCommands: []yaml.Command{{Run: "echo {{ .ProjectDir }}", Env: map[string]string{"SHA": "{{ .HeadSHA }}"}}},
+	}
+	project := yaml.Project{
+		Dir:            "infra",
+		Workspace:      "prod",
+		PodAnnotations: map[string]string{"pr": "{{ .PullRequest }}"},
+		LoadedWorkflow: workflow,
+	}
+
+	rendered, err := New(project, Environment{PullRequest: 7, HeadSHA: "abc123"}).Render(project)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if v := rendered.LoadedWorkflow.Env["TF_CLI_ARGS_init"]; v != "-backend-config=prod.hcl" {
+		t.Errorf("expected workflow env to be rendered, got %q", v)
+	}
+	if v := rendered.PodAnnotations["pr"]; v != "7" {
+		t.Errorf("expected pod annotations to be rendered, got %q", v)
+	}
+	if cmd := rendered.LoadedWorkflow.InitCommands[0]; cmd.Run != "echo infra" || cmd.Env["SHA"] != "abc123" {
+		t.Errorf("expected init command to be rendered, got %+v", cmd)
+	}
+
+	// The workflow is shared between projects, so it must not be modified.
+	if v := workflow.Env["TF_CLI_ARGS_init"]; v != "-backend-config={{ .Workspace }}.hcl" {
+		t.Errorf("expected the original workflow to be unchanged, got %q", v)
+	}
+	if v := workflow.InitCommands[0].Run; v != "echo {{ .ProjectDir }}" {
+		t.Errorf("expected the original commands to be unchanged, got %q", v)
+	}
+}
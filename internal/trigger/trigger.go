@@ -0,0 +1,106 @@
+package trigger
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/bluekeyes/go-gitdiff/gitdiff"
+	"github.com/bmatcuk/doublestar"
+	"github.com/charmbracelet/log"
+
+	"github.com/ivanvc/turnip/internal/discovery"
+	"github.com/ivanvc/turnip/internal/plugin"
+	"github.com/ivanvc/turnip/internal/yaml"
+)
+
+// Trigger tells whether the changes trigger a project, and why.
+type Trigger struct {
+	Project *yaml.Project
+	// Rules are the project's whenModified rules, relative to its directory.
+	Rules []string
+	// Path is the first changed path matching the rules, and Rule the rule it
+	// matched. Both are empty if no path matched.
+	Path string
+	Rule string
+	// AutoPlotDisabled is set when only the projects with auto plot are
+	// triggered, and the project doesn't set it.
+	AutoPlotDisabled bool
+}
+
+// Triggered returns whether the project runs.
+func (t Trigger) Triggered() bool {
+	return t.Path != "" && !t.AutoPlotDisabled
+}
+
+// Projects checks every project in the configuration against the changed
+// paths. With autoPlot, only the projects that set their adapter's auto plot
+// flag are triggered. The default rules of some adapters are derived from the
+// source's files.
+func Projects(cfg yaml.Config, paths []string, autoPlot bool, src discovery.Source) ([]Trigger, error) {
+	files := plugin.NewFiles(src)
+	triggers := make([]Trigger, 0, len(cfg.Projects))
+	for i := range cfg.Projects {
+		prj := &cfg.Projects[i]
+		p, err := plugin.ForProject(prj)
+		if err != nil {
+			return nil, err
+		}
+		t := Trigger{Project: prj, AutoPlotDisabled: autoPlot && !p.AutoPlan(prj)}
+		for _, rule := range plugin.WhenModified(prj, files) {
+			if !strings.HasPrefix(rule, "..") && !strings.HasPrefix(rule, "./") {
+				rule = fmt.Sprintf("./%s", rule)
+			}
+			t.Rules = append(t.Rules, rule)
+		}
+
+		for _, path := range paths {
+			rule, ok, err := MatchRules(prj.Dir, path, t.Rules)
+			if err != nil {
+				log.Error("error checking path", "error", err)
+				continue
+			}
+			if ok {
+				t.Path, t.Rule = path, rule
+				break
+			}
+		}
+		log.Debug("checked project", "project", prj.DisplayName(), "triggered", t.Triggered(), "path", t.Path, "rule", t.Rule)
+		triggers = append(triggers, t)
+	}
+	return triggers, nil
+}
+
+// ChangedPaths returns the paths of the changed files, the old one for the
+// deleted files.
+func ChangedPaths(changes []*gitdiff.File) []string {
+	paths := make([]string, 0, len(changes))
+	for _, change := range changes {
+		if change.IsDelete {
+			paths = append(paths, change.OldName)
+		} else {
+			paths = append(paths, change.NewName)
+		}
+	}
+	return paths
+}
+
+// MatchRules returns the first rule matching the path. The rules are relative
+// to dir.
+func MatchRules(dir, path string, rules []string) (string, bool, error) {
+	relPath, err := filepath.Rel(dir, path)
+	if err != nil {
+		return "", false, err
+	}
+	if len(relPath) > 2 && relPath[0:2] != ".." {
+		relPath = fmt.Sprintf("./%s", relPath)
+	}
+	for _, rule := range rules {
+		log.Debug("checking rule", "value", rule)
+		if ok, _ := doublestar.Match(rule, relPath); ok {
+			log.Debug("rule matched", "rule", rule, "path", path)
+			return rule, true, nil
+		}
+	}
+	return "", false, nil
+}
@@ -0,0 +1,73 @@
+package trigger
+
+import (
+	"testing"
+
+	"github.com/ivanvc/turnip/internal/discovery"
+	"github.com/ivanvc/turnip/internal/yaml"
+)
+
+func TestMatchRules(t *testing.T) {
+	tt := []struct {
+		dir      string
+		rules    []string
+		path     string
+		expected string
+	}{
+		{"infra", []string{"./**/*"}, "infra/main.go", "./**/*"},
+		{"infra", []string{"../infra.yaml", "./*.go"}, "infra.yaml", "../infra.yaml"},
+		{"infra", []string{"../infra.yaml", "./*.go"}, "infra/main.go", "./*.go"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.dir, func(t *testing.T) {
+			if rule, ok, err := MatchRules(tc.dir, tc.path, tc.rules); err != nil {
+				t.Errorf("Error checking path: %v", err)
+			} else if !ok {
+				t.Errorf("Expected path to match rules, rules: %v, path: %v", tc.rules, tc.path)
+			} else if rule != tc.expected {
+				t.Errorf("Expected rule %v to match, got %v", tc.expected, rule)
+			}
+		})
+	}
+}
+
+func TestProjects(t *testing.T) {
+	cfg, err := yaml.Load([]byte(`version: v1beta1
+workflows:
+  tf:
+    adapter:
+      type: terraform
+      version: 1.9.0
+projects:
+  - dir: infra/network
+    autoPlot: true
+    workflow: tf
+  - dir: infra/app
+    whenModified: ["./*.tf", "../modules/**"]
+    workflow: tf
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := discovery.DirSource(t.TempDir())
+	paths := []string{"infra/modules/vpc/main.tf"}
+
+	tt := []struct {
+		autoPlot bool
+		expected []bool
+	}{
+		{false, []bool{false, true}},
+		{true, []bool{false, false}},
+	}
+	for _, tc := range tt {
+		triggers, err := Projects(cfg, paths, tc.autoPlot, src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, tr := range triggers {
+			if tr.Triggered() != tc.expected[i] {
+				t.Errorf("autoPlot %v: expected project %s triggered to be %v", tc.autoPlot, tr.Project.Dir, tc.expected[i])
+			}
+		}
+	}
+}
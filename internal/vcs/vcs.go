@@ -0,0 +1,139 @@
+package vcs
+
+import (
+	"strings"
+
+	"github.com/ivanvc/turnip/internal/discovery"
+)
+
+// Comment is a comment on a pull request.
+type Comment struct {
+	URL  string
+	Body string
+	// NodeID identifies the comment in the GitHub GraphQL API.
+	NodeID string
+}
+
+// Client reports the jobs to a VCS, through commit statuses and pull request
+// comments. The URLs are the ones returned by the client, or received in its
+// webhooks, and go through the runner and back.
+type Client interface {
+	// CreateCheckRun creates the pending status of the commit, with the
+	// description, and returns its URL.
+	CreateCheckRun(statusesURL, sha, name, description string) (string, error)
+	StartCheckRun(checkURL, checkName string) error
+	// FinishCheckRun sets the status to its conclusion, success or failure.
+	FinishCheckRun(checkURL, checkName, conclusion string) error
+	// UpdateCheckRun sets the state of the status, pending or error, with a
+	// description.
+	UpdateCheckRun(checkURL, checkName, state, description string) error
+	CreateComment(commentsURL, body string) error
+	ListComments(commentsURL string) ([]Comment, error)
+	UpdateComment(commentURL, body string) error
+	// HeadSHA returns the current head commit of the pull request the
+	// comments belong to, or an empty string if they aren't a pull request's.
+	HeadSHA(commentsURL string) (string, error)
+	// MinimizeComment hides the comment, using the classifier as the reason
+	// (i.e. OUTDATED), if the VCS supports it.
+	MinimizeComment(c Comment, classifier string) error
+}
+
+// Ref is a revision of a repository.
+type Ref struct {
+	Ref string
+	SHA string
+	// Repo is the full name of the repository.
+	Repo string
+	// RepoURL is the API URL of the repository.
+	RepoURL string
+}
+
+// PullRequest is a GitHub pull request, or a GitLab merge request.
+type PullRequest struct {
+	URL         string
+	Number      int
+	CloneURL    string
+	StatusesURL string
+	CommentsURL string
+	Head        Ref
+	Base        Ref
+}
+
+// PullRequestClient gives access to the pull requests of a VCS.
+type PullRequestClient interface {
+	Client
+	// GetPullRequestDiff returns the unified diff of the pull request.
+	GetPullRequestDiff(pr *PullRequest) ([]byte, error)
+	// IsApproved returns whether the pull request's head commit is approved.
+	IsApproved(pr *PullRequest) (bool, error)
+	// NewSource returns the files of the repository at the revision.
+	NewSource(ref Ref) discovery.Source
+}
+
+type route struct {
+	prefix string
+	client Client
+}
+
+// Mux is a Client that routes the calls to the client of the VCS the URLs
+// belong to.
+type Mux struct {
+	routes   []route
+	fallback Client
+}
+
+// NewMux returns a Mux routing to fallback the URLs that don't match any
+// other client.
+func NewMux(fallback Client) *Mux {
+	return &Mux{fallback: fallback}
+}
+
+// Handle routes the URLs starting with prefix to the client.
+func (m *Mux) Handle(prefix string, c Client) {
+	m.routes = append(m.routes, route{prefix, c})
+}
+
+func (m *Mux) client(u string) Client {
+	for _, r := range m.routes {
+		if strings.HasPrefix(u, r.prefix) {
+			return r.client
+		}
+	}
+	return m.fallback
+}
+
+func (m *Mux) CreateCheckRun(statusesURL, sha, name, description string) (string, error) {
+	return m.client(statusesURL).CreateCheckRun(statusesURL, sha, name, description)
+}
+
+func (m *Mux) StartCheckRun(checkURL, checkName string) error {
+	return m.client(checkURL).StartCheckRun(checkURL, checkName)
+}
+
+func (m *Mux) FinishCheckRun(checkURL, checkName, conclusion string) error {
+	return m.client(checkURL).FinishCheckRun(checkURL, checkName, conclusion)
+}
+
+func (m *Mux) UpdateCheckRun(checkURL, checkName, state, description string) error {
+	return m.client(checkURL).UpdateCheckRun(checkURL, checkName, state, description)
+}
+
+func (m *Mux) CreateComment(commentsURL, body string) error {
+	return m.client(commentsURL).CreateComment(commentsURL, body)
+}
+
+func (m *Mux) ListComments(commentsURL string) ([]Comment, error) {
+	return m.client(commentsURL).ListComments(commentsURL)
+}
+
+func (m *Mux) UpdateComment(commentURL, body string) error {
+	return m.client(commentURL).UpdateComment(commentURL, body)
+}
+
+func (m *Mux) HeadSHA(commentsURL string) (string, error) {
+	return m.client(commentsURL).HeadSHA(commentsURL)
+}
+
+func (m *Mux) MinimizeComment(c Comment, classifier string) error {
+	return m.client(c.URL).MinimizeComment(c, classifier)
+}
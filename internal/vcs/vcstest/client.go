@@ -0,0 +1,55 @@
+// Package vcstest provides a vcs.Client for tests.
+package vcstest
+
+import (
+	"strconv"
+
+	"github.com/ivanvc/turnip/internal/vcs"
+)
+
+// Client keeps the comments of a single pull request in memory. The comment
+// URLs are their index. The check runs are accepted and ignored.
+type Client struct {
+	// Head is the head commit HeadSHA returns.
+	Head     string
+	Comments []vcs.Comment
+	// Minimized holds the URLs of the minimized comments.
+	Minimized []string
+	// Created and Updated count the comments created and updated.
+	Created int
+	Updated int
+}
+
+var _ vcs.Client = (*Client)(nil)
+
+func (c *Client) CreateCheckRun(statusesURL, sha, name, description string) (string, error) {
+	return "", nil
+}
+func (c *Client) StartCheckRun(checkURL, checkName string) error              { return nil }
+func (c *Client) FinishCheckRun(checkURL, checkName, conclusion string) error { return nil }
+func (c *Client) UpdateCheckRun(checkURL, checkName, state, description string) error {
+	return nil
+}
+func (c *Client) HeadSHA(commentsURL string) (string, error)             { return c.Head, nil }
+func (c *Client) ListComments(commentsURL string) ([]vcs.Comment, error) { return c.Comments, nil }
+
+func (c *Client) CreateComment(commentsURL, body string) error {
+	c.Created++
+	c.Comments = append(c.Comments, vcs.Comment{URL: strconv.Itoa(len(c.Comments)), Body: body})
+	return nil
+}
+
+func (c *Client) UpdateComment(commentURL, body string) error {
+	c.Updated++
+	for i := range c.Comments {
+		if c.Comments[i].URL == commentURL {
+			c.Comments[i].Body = body
+		}
+	}
+	return nil
+}
+
+func (c *Client) MinimizeComment(comment vcs.Comment, classifier string) error {
+	c.Minimized = append(c.Minimized, comment.URL)
+	return nil
+}
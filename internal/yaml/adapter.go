@@ -1,13 +1,17 @@
 package yaml
 
-import "fmt"
+import (
+	"fmt"
+	"slices"
+)
 
 type Adapter interface {
 	GetName() string
-	GetPlotName() string
-	GetLiftName() string
 	GetVersion() string
 	GetWorkspace(Project) string
+	// WithoutInstall returns a copy of the adapter that uses the tools in
+	// the PATH, instead of installing them.
+	WithoutInstall() Adapter
 	Validate() error
 }
 
@@ -18,10 +22,13 @@ type HelmfileAdapter struct {
 }
 
 func (HelmfileAdapter) GetName() string                 { return "helmfile" }
-func (HelmfileAdapter) GetPlotName() string             { return "diff" }
-func (HelmfileAdapter) GetLiftName() string             { return "apply" }
 func (a HelmfileAdapter) GetVersion() string            { return a.Version }
 func (a HelmfileAdapter) GetWorkspace(p Project) string { return p.Environment }
+func (a HelmfileAdapter) WithoutInstall() Adapter {
+	a.SkipInstall, a.Version, a.VersionFrom = true, "", ""
+	return &a
+}
+
 func (a HelmfileAdapter) Validate() error {
 	return validateAdapter(a.Version, a.VersionFrom, a.SkipInstall)
 }
@@ -33,14 +40,169 @@ type TerraformAdapter struct {
 }
 
 func (TerraformAdapter) GetName() string                 { return "terraform" }
-func (TerraformAdapter) GetPlotName() string             { return "plan" }
-func (TerraformAdapter) GetLiftName() string             { return "apply" }
 func (a TerraformAdapter) GetVersion() string            { return a.Version }
 func (a TerraformAdapter) GetWorkspace(p Project) string { return p.Workspace }
+func (a TerraformAdapter) WithoutInstall() Adapter {
+	a.SkipInstall, a.Version, a.VersionFrom = true, "", ""
+	return &a
+}
+
 func (a TerraformAdapter) Validate() error {
 	return validateAdapter(a.Version, a.VersionFrom, a.SkipInstall)
 }
 
+type TofuAdapter struct {
+	Version     string `yaml:"version"`
+	VersionFrom string `yaml:"versionFrom"`
+	SkipInstall bool   `yaml:"skipInstall"`
+	Workspace   string `yaml:"workspace"`
+}
+
+func (TofuAdapter) GetName() string      { return "tofu" }
+func (a TofuAdapter) GetVersion() string { return a.Version }
+
+// GetWorkspace returns the project's workspace, falling back to the one set in
+// the adapter.
+func (a TofuAdapter) GetWorkspace(p Project) string {
+	if p.Workspace != "" {
+		return p.Workspace
+	}
+	return a.Workspace
+}
+
+func (a TofuAdapter) WithoutInstall() Adapter {
+	a.SkipInstall, a.Version, a.VersionFrom = true, "", ""
+	return &a
+}
+
+func (a TofuAdapter) Validate() error {
+	return validateAdapter(a.Version, a.VersionFrom, a.SkipInstall)
+}
+
+type TerragruntAdapter struct {
+	Version     string `yaml:"version"`
+	VersionFrom string `yaml:"versionFrom"`
+	SkipInstall bool   `yaml:"skipInstall"`
+	// Engine is the binary Terragrunt runs, either terraform (the default) or
+	// tofu.
+	Engine string `yaml:"engine"`
+	// EngineVersion is the version of the engine to install, required unless
+	// SkipInstall is set.
+	EngineVersion string `yaml:"engineVersion"`
+	// RunAll runs the commands on every module under the project's directory.
+	RunAll bool `yaml:"runAll"`
+}
+
+func (TerragruntAdapter) GetName() string                 { return "terragrunt" }
+func (a TerragruntAdapter) GetVersion() string            { return a.Version }
+func (a TerragruntAdapter) GetWorkspace(p Project) string { return p.Workspace }
+
+// GetEngine returns the name of the engine, defaulting to terraform.
+func (a TerragruntAdapter) GetEngine() string {
+	if a.Engine == "" {
+		return "terraform"
+	}
+	return a.Engine
+}
+
+func (a TerragruntAdapter) WithoutInstall() Adapter {
+	a.SkipInstall, a.Version, a.VersionFrom = true, "", ""
+	return &a
+}
+
+func (a TerragruntAdapter) Validate() error {
+	if e := a.GetEngine(); e != "terraform" && e != "tofu" {
+		return fmt.Errorf("engine must be one of terraform or tofu, got %s", e)
+	}
+	if a.EngineVersion == "" && !a.SkipInstall {
+		return fmt.Errorf("engineVersion or skipInstall must be set")
+	}
+	return validateAdapter(a.Version, a.VersionFrom, a.SkipInstall)
+}
+
+type KustomizeAdapter struct {
+	Version     string `yaml:"version"`
+	VersionFrom string `yaml:"versionFrom"`
+	SkipInstall bool   `yaml:"skipInstall"`
+	// Context is the kube context to use, unless the project sets a workspace.
+	Context   string `yaml:"context"`
+	Namespace string `yaml:"namespace"`
+	// Prune deletes the objects that are no longer in the manifests. It's
+	// limited to the objects matching PruneSelector, if set.
+	Prune         bool   `yaml:"prune"`
+	PruneSelector string `yaml:"pruneSelector"`
+}
+
+func (KustomizeAdapter) GetName() string      { return "kustomize" }
+func (a KustomizeAdapter) GetVersion() string { return a.Version }
+
+// GetWorkspace returns the kube context, the project's workspace falling back
+// to the one set in the adapter.
+func (a KustomizeAdapter) GetWorkspace(p Project) string {
+	if p.Workspace != "" {
+		return p.Workspace
+	}
+	return a.Context
+}
+
+func (a KustomizeAdapter) WithoutInstall() Adapter {
+	a.SkipInstall, a.Version, a.VersionFrom = true, "", ""
+	return &a
+}
+
+func (a KustomizeAdapter) Validate() error {
+	if a.PruneSelector != "" && !a.Prune {
+		return fmt.Errorf("pruneSelector requires prune to be set")
+	}
+	return validateAdapter(a.Version, a.VersionFrom, a.SkipInstall)
+}
+
+// CustomAdapter runs user defined commands.
+type CustomAdapter struct {
+	Install []Command `yaml:"install"`
+	Plot    []Command `yaml:"plot"`
+	Lift    []Command `yaml:"lift"`
+	// ExitCodes tells the exit codes of the plot commands that are not
+	// errors.
+	ExitCodes ExitCodes `yaml:"exitCodes"`
+}
+
+// ExitCodes are the exit codes that tell whether there are changes.
+type ExitCodes struct {
+	Changes   []int `yaml:"changes"`
+	NoChanges []int `yaml:"noChanges"`
+}
+
+func (CustomAdapter) GetName() string                 { return "custom" }
+func (CustomAdapter) GetVersion() string              { return "" }
+func (a CustomAdapter) GetWorkspace(p Project) string { return p.Workspace }
+func (a CustomAdapter) WithoutInstall() Adapter {
+	a.Install = nil
+	return &a
+}
+
+func (a CustomAdapter) Validate() error {
+	if len(a.Plot) == 0 {
+		return fmt.Errorf("plot commands must be set")
+	}
+	if len(a.Lift) == 0 {
+		return fmt.Errorf("lift commands must be set")
+	}
+	for _, cmds := range [][]Command{a.Install, a.Plot, a.Lift} {
+		for _, c := range cmds {
+			if c.Run == "" {
+				return fmt.Errorf("run must be set in every command")
+			}
+		}
+	}
+	for _, code := range a.ExitCodes.Changes {
+		if slices.Contains(a.ExitCodes.NoChanges, code) {
+			return fmt.Errorf("exit code %d can't tell both changes and no changes", code)
+		}
+	}
+	return nil
+}
+
 type PulumiAdapter struct {
 	Version     string `yaml:"version"`
 	VersionFrom string `yaml:"versionFrom"`
@@ -48,10 +210,13 @@ type PulumiAdapter struct {
 }
 
 func (PulumiAdapter) GetName() string                 { return "pulumi" }
-func (PulumiAdapter) GetPlotName() string             { return "preview" }
-func (PulumiAdapter) GetLiftName() string             { return "up" }
 func (a PulumiAdapter) GetVersion() string            { return a.Version }
 func (a PulumiAdapter) GetWorkspace(p Project) string { return p.Stack }
+func (a PulumiAdapter) WithoutInstall() Adapter {
+	a.SkipInstall, a.Version, a.VersionFrom = true, "", ""
+	return &a
+}
+
 func (a PulumiAdapter) Validate() error {
 	return validateAdapter(a.Version, a.VersionFrom, a.SkipInstall)
 }
@@ -0,0 +1,58 @@
+package yaml
+
+import (
+	"fmt"
+	"slices"
+)
+
+// AutodiscoverTypes are the kinds of projects that can be discovered.
+var AutodiscoverTypes = []string{PulumiAdapter{}.GetName(), TerraformAdapter{}.GetName()}
+
+// Autodiscover holds the rules to discover projects from the repository's
+// layout.
+type Autodiscover struct {
+	Rules []AutodiscoverRule `yaml:"rules"`
+}
+
+// AutodiscoverRule turns the directories matching it into projects.
+type AutodiscoverRule struct {
+	// Type is the kind of projects to discover. A pulumi project is a
+	// directory with a Pulumi.yaml, and a project is created for every
+	// Pulumi.<stack>.yaml next to it. A terraform project is a directory with
+	// .tf files declaring a backend.
+	Type     string `yaml:"type"`
+	Workflow string `yaml:"workflow"`
+	// Paths are the globs of the directories to look in, it defaults to
+	// every directory. They're required by terraform rules, as the .tf files
+	// of the directories are fetched to find the backends.
+	Paths   []string `yaml:"paths"`
+	Exclude []string `yaml:"exclude"`
+	// AutoPlot plots the discovered projects when they're modified.
+	AutoPlot bool `yaml:"autoPlot"`
+}
+
+func (r AutodiscoverRule) Validate() error {
+	if !slices.Contains(AutodiscoverTypes, r.Type) {
+		return fmt.Errorf("type must be one of %v, got %q", AutodiscoverTypes, r.Type)
+	}
+	if r.Workflow == "" {
+		return fmt.Errorf("workflow not set")
+	}
+	if r.Type == "terraform" && len(r.Paths) == 0 {
+		return fmt.Errorf("paths not set")
+	}
+	return nil
+}
+
+// Project returns the project for a directory discovered by the rule.
+func (r AutodiscoverRule) Project(dir, stack string, workflow Workflow) Project {
+	return Project{
+		Dir:            dir,
+		Stack:          stack,
+		AutoPlan:       r.AutoPlot,
+		AutoPreview:    r.AutoPlot,
+		AutoDiff:       r.AutoPlot,
+		Workflow:       r.Workflow,
+		LoadedWorkflow: workflow,
+	}
+}
@@ -1,16 +1,60 @@
 package yaml
 
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// NoShell is the Shell value to run commands without a shell.
+const NoShell = "none"
+
 type Command struct {
 	Env        map[string]string `yaml:"env"`
 	Run        string            `yaml:"run"`
 	Pulumi     string            `yaml:"pulumi"`
 	OmitOutput bool              `yaml:"omitOutput"`
+
+	// Shell runs Run with `<shell> -c`, it defaults to sh. If set to none,
+	// Run is split in fields and executed directly.
+	Shell string `yaml:"shell"`
+	// WorkingDir is relative to the project's directory.
+	WorkingDir string        `yaml:"workingDir"`
+	Timeout    time.Duration `yaml:"timeout"`
+	// ContinueOnError runs the next commands even if this one fails.
+	ContinueOnError bool `yaml:"continueOnError"`
+}
+
+// GetShell returns the shell to run the command with, or an empty string if
+// it runs without one.
+func (c Command) GetShell() string {
+	switch c.Shell {
+	case "":
+		return "sh"
+	case NoShell:
+		return ""
+	default:
+		return c.Shell
+	}
 }
 
 func (c Command) GetEnv() []string {
 	return envToSlice(c.Env)
 }
 
+// envPrefix is reserved for the variables turnip passes to the runner.
+const envPrefix = "TURNIP_"
+
+// validateEnv checks that env doesn't set any of the runner's variables.
+func validateEnv(env map[string]string) error {
+	for k := range env {
+		if strings.HasPrefix(k, envPrefix) {
+			return fmt.Errorf("env %s: the %s prefix is reserved", k, envPrefix)
+		}
+	}
+	return nil
+}
+
 func envToSlice(env map[string]string) []string {
 	output := make([]string, 0)
 	for k, v := range env {
@@ -2,6 +2,9 @@ package yaml
 
 import (
 	"fmt"
+	"path"
+	"slices"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -11,13 +14,38 @@ type Config struct {
 	Projects  []Project           `yaml:"projects"`
 	Workflows map[string]Workflow `yaml:"workflows"`
 	Version   string              `yaml:"version"`
+
+	// Include are the globs of the files with more projects, e.g.
+	// infra/**/turnip.project.yaml.
+	Include []string `yaml:"include"`
+
+	Autodiscover *Autodiscover `yaml:"autodiscover"`
 }
 
 func Load(data []byte) (Config, error) {
-	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
+	return LoadWithPolicy(data, Policy{})
+}
+
+// LoadWithPolicy loads the configuration, checking it against the server's
+// policy, and adding the server's workflows to it.
+func LoadWithPolicy(data []byte, policy Policy) (Config, error) {
+	cfg, err := decodeVersioned("turnip.yaml", data)
+	if err != nil {
 		return cfg, err
 	}
+	if err := policy.CheckKeys("turnip.yaml", cfg); err != nil {
+		return Config{}, err
+	}
+
+	for name, w := range policy.Workflows {
+		if _, ok := cfg.Workflows[name]; ok {
+			return cfg, fmt.Errorf("workflow %s is defined by the server and can't be overridden", name)
+		}
+		if cfg.Workflows == nil {
+			cfg.Workflows = make(map[string]Workflow)
+		}
+		cfg.Workflows[name] = w
+	}
 
 	for i, p := range cfg.Projects {
 		if w, ok := cfg.Workflows[p.Workflow]; ok {
@@ -30,11 +58,24 @@ func Load(data []byte) (Config, error) {
 }
 
 func (c Config) Validate() error {
-	if c.Version != "v1alpha1" {
+	if !slices.Contains(Versions, c.Version) {
 		return fmt.Errorf("unsupported turnip.yaml version: %s", c.Version)
 	}
 
+	names := make(map[string]string)
+	projects := make(map[string]struct{})
 	for _, p := range c.Projects {
+		key := path.Clean(p.Dir) + ":" + p.GetWorkspace()
+		if _, ok := projects[key]; ok {
+			return fmt.Errorf("project %s: defined more than once", strings.TrimSuffix(key, ":"))
+		}
+		projects[key] = struct{}{}
+		if p.Name != "" {
+			if dir, ok := names[p.Name]; ok {
+				return fmt.Errorf("project %s: name %s already used by project %s", p.Dir, p.Name, dir)
+			}
+			names[p.Name] = p.Dir
+		}
 		if err := p.Validate(); err != nil {
 			return fmt.Errorf("project %s: %s", p.Dir, err.Error())
 		}
@@ -43,11 +84,49 @@ func (c Config) Validate() error {
 		}
 	}
 
+	if c.Autodiscover != nil {
+		for i, r := range c.Autodiscover.Rules {
+			if err := r.Validate(); err != nil {
+				return fmt.Errorf("autodiscover rule %d: %s", i, err.Error())
+			}
+			if _, ok := c.Workflows[r.Workflow]; !ok {
+				return fmt.Errorf("autodiscover rule %d: workflow %s not found", i, r.Workflow)
+			}
+		}
+	}
+
 	for name, w := range c.Workflows {
 		if err := w.Validate(); err != nil {
 			return fmt.Errorf("workflow %s: %s", name, err.Error())
 		}
 	}
 
-	return nil
+	// The references may point to projects that are yet to be included or
+	// discovered.
+	return c.validateDependencies(c.Autodiscover == nil && len(c.Include) == 0)
+}
+
+// decodeVersioned decodes the configuration as the version it declares,
+// converting it to Config.
+func decodeVersioned(file string, data []byte) (Config, error) {
+	var header struct {
+		Version string `yaml:"version"`
+	}
+	if err := yaml.Unmarshal(data, &header); err != nil {
+		return Config{}, newConfigError(file, data, err)
+	}
+
+	switch header.Version {
+	case V1beta1:
+		var cfg ConfigV1beta1
+		if err := decodeStrict(file, data, &cfg); err != nil {
+			return Config{}, err
+		}
+		return cfg.Convert(), nil
+	default:
+		// Validate rejects the unsupported versions.
+		var cfg Config
+		err := decodeStrict(file, data, &cfg)
+		return cfg, err
+	}
 }
@@ -0,0 +1,79 @@
+package yaml
+
+import "testing"
+
+func TestValidateNames(t *testing.T) {
+	workflows := map[string]Workflow{"tf": {Terraform: &TerraformAdapter{Version: "1.9.0"}}}
+
+	tt := []struct {
+		name     string
+		projects []Project
+		expected string
+	}{
+		{
+			"unique",
+			[]Project{{Name: "network", Dir: "infra/network", Workflow: "tf"}, {Dir: "infra/cluster", Workflow: "tf"}},
+			"",
+		},
+		{
+			"duplicated",
+			[]Project{{Name: "network", Dir: "infra/network", Workflow: "tf"}, {Name: "network", Dir: "infra/vpc", Workflow: "tf"}},
+			"project infra/vpc: name network already used by project infra/network",
+		},
+		{
+			"invalid",
+			[]Project{{Name: "infra/network", Dir: "infra/network", Workflow: "tf"}},
+			`project infra/network: invalid name "infra/network", it must start with a letter or number, followed by letters, numbers, '.', '_' or '-'`,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Config{Version: "v1alpha1", Projects: tc.projects, Workflows: workflows}.Validate()
+			switch {
+			case tc.expected == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tc.expected != "" && (err == nil || err.Error() != tc.expected):
+				t.Errorf("expected error %q, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestValidateReservedEnv(t *testing.T) {
+	tt := []struct {
+		name     string
+		project  Project
+		workflow Workflow
+		expected string
+	}{
+		{
+			"project env",
+			Project{Dir: "infra", Workflow: "tf", Env: map[string]string{"TURNIP_SECRET_ENV_NAMES": ""}},
+			Workflow{Terraform: &TerraformAdapter{Version: "1.9.0"}},
+			"project infra: env TURNIP_SECRET_ENV_NAMES: the TURNIP_ prefix is reserved",
+		},
+		{
+			"workflow env",
+			Project{Dir: "infra", Workflow: "tf"},
+			Workflow{Terraform: &TerraformAdapter{Version: "1.9.0"}, Env: map[string]string{"TURNIP_REDACT_PATTERNS": "[]"}},
+			"workflow tf: env TURNIP_REDACT_PATTERNS: the TURNIP_ prefix is reserved",
+		},
+		{
+			"other env",
+			Project{Dir: "infra", Workflow: "tf", Env: map[string]string{"TF_LOG": "info"}},
+			Workflow{Terraform: &TerraformAdapter{Version: "1.9.0"}},
+			"",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Config{Version: "v1alpha1", Projects: []Project{tc.project}, Workflows: map[string]Workflow{"tf": tc.workflow}}.Validate()
+			switch {
+			case tc.expected == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tc.expected != "" && (err == nil || err.Error() != tc.expected):
+				t.Errorf("expected error %q, got %v", tc.expected, err)
+			}
+		})
+	}
+}
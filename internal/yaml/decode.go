@@ -0,0 +1,232 @@
+package yaml
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// internalKeyPrefix is the prefix of the keys set by turnip, which can't be
+// set in the configuration files.
+const internalKeyPrefix = "__"
+
+var errorLineRegexp = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// ConfigError is an error in a configuration file. Line and Column are 0 if
+// unknown.
+type ConfigError struct {
+	File   string
+	Line   int
+	Column int
+	// Source is the offending line.
+	Source string
+	Msg    string
+}
+
+func (e *ConfigError) Error() string {
+	switch {
+	case e.Line == 0:
+		return fmt.Sprintf("%s: %s", e.File, e.Msg)
+	case e.Column == 0:
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Msg)
+	default:
+		return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Msg)
+	}
+}
+
+// Details returns the error followed by the offending line, marking the
+// column if known.
+func (e *ConfigError) Details() string {
+	if e.Source == "" {
+		return e.Error()
+	}
+	details := fmt.Sprintf("%s\n\n%4d | %s", e.Error(), e.Line, e.Source)
+	if e.Column > 0 {
+		details += "\n" + strings.Repeat(" ", 7+e.Column-1) + "^"
+	}
+	return details
+}
+
+// decodeStrict decodes the configuration file into out, rejecting the unknown
+// and internal keys.
+func decodeStrict(file string, data []byte, out any) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return newConfigError(file, data, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	if err := checkFields(&doc, reflect.TypeOf(out)); err != nil {
+		err.File = file
+		err.Source = sourceLine(data, err.Line)
+		return err
+	}
+	if err := doc.Decode(out); err != nil {
+		return newConfigError(file, data, err)
+	}
+	return nil
+}
+
+// newConfigError returns the error from the YAML decoder, with its line if it
+// has one.
+func newConfigError(file string, data []byte, err error) *ConfigError {
+	msg := err.Error()
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) && len(typeErr.Errors) > 0 {
+		msg = typeErr.Errors[0]
+		if n := len(typeErr.Errors) - 1; n > 0 {
+			msg += fmt.Sprintf(" (and %d more errors)", n)
+		}
+	}
+
+	e := &ConfigError{File: file, Msg: strings.TrimPrefix(msg, "yaml: ")}
+	if m := errorLineRegexp.FindStringSubmatch(msg); m != nil {
+		e.Line, _ = strconv.Atoi(m[1])
+		e.Msg = m[2]
+		e.Source = sourceLine(data, e.Line)
+	}
+	return e
+}
+
+// fieldsChecker is implemented by the types that decode their node
+// themselves, to check it.
+type fieldsChecker interface {
+	checkYAMLFields(n *yaml.Node) *ConfigError
+}
+
+// checkFields returns an error for the first key in the node that's not a
+// field of t.
+func checkFields(n *yaml.Node, t reflect.Type) *ConfigError {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, c := range n.Content {
+			if err := checkFields(c, t); err != nil {
+				return err
+			}
+		}
+		return nil
+	case yaml.AliasNode:
+		return checkFields(n.Alias, t)
+	}
+	if c, ok := reflect.Zero(t).Interface().(fieldsChecker); ok {
+		return c.checkYAMLFields(n)
+	}
+
+	switch {
+	case t.Kind() == reflect.Struct && n.Kind == yaml.MappingNode:
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key := n.Content[i]
+			ft, ok := fields[key.Value]
+			if !ok || strings.HasPrefix(key.Value, internalKeyPrefix) {
+				return &ConfigError{
+					Line:   key.Line,
+					Column: key.Column,
+					Msg:    unknownFieldMessage(key.Value, t, fields),
+				}
+			}
+			if err := checkFields(n.Content[i+1], ft); err != nil {
+				return err
+			}
+		}
+	case t.Kind() == reflect.Map && n.Kind == yaml.MappingNode:
+		for i := 1; i < len(n.Content); i += 2 {
+			if err := checkFields(n.Content[i], t.Elem()); err != nil {
+				return err
+			}
+		}
+	case t.Kind() == reflect.Slice && n.Kind == yaml.SequenceNode:
+		for _, c := range n.Content {
+			if err := checkFields(c, t.Elem()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// yamlFields returns the types of the struct's fields by their key.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		if name, ok := yamlKey(t.Field(i)); ok {
+			fields[name] = t.Field(i).Type
+		}
+	}
+	return fields
+}
+
+// yamlKey returns the key of the struct field, or false if it isn't decoded.
+func yamlKey(f reflect.StructField) (string, bool) {
+	if !f.IsExported() {
+		return "", false
+	}
+	name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+	switch name {
+	case "-":
+		return "", false
+	case "":
+		return strings.ToLower(f.Name), true
+	default:
+		return name, true
+	}
+}
+
+// unknownFieldMessage returns the error message for an unknown key,
+// suggesting the closest field if it looks like a typo.
+func unknownFieldMessage(key string, t reflect.Type, fields map[string]reflect.Type) string {
+	msg := fmt.Sprintf("unknown field %q in %s", key, t.Name())
+	best, bestDistance := "", 3
+	for name := range fields {
+		if strings.HasPrefix(name, internalKeyPrefix) {
+			continue
+		}
+		d := levenshtein(strings.ToLower(key), strings.ToLower(name))
+		if d < bestDistance || (d == bestDistance && name < best) {
+			best, bestDistance = name, d
+		}
+	}
+	if best != "" {
+		msg += fmt.Sprintf(", did you mean %q?", best)
+	}
+	return msg
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// sourceLine returns the line of data, starting at 1.
+func sourceLine(data []byte, line int) string {
+	lines := strings.Split(string(data), "\n")
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	return strings.TrimRight(lines[line-1], "\r")
+}
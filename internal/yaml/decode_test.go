@@ -0,0 +1,80 @@
+package yaml
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestLoadStrict(t *testing.T) {
+	tt := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			"typo",
+			"version: v1alpha1\nprojects:\n  - dir: infra\n    autoplan: true\n",
+			`turnip.yaml:4:5: unknown field "autoplan" in Project, did you mean "autoPlan"?`,
+		},
+		{
+			"misspelled",
+			"version: v1alpha1\nprojects:\n  - dir: infra\n    whenModifed: [\"*.tf\"]\n",
+			`turnip.yaml:4:5: unknown field "whenModifed" in Project, did you mean "whenModified"?`,
+		},
+		{
+			"internal key",
+			"version: v1alpha1\nprojects:\n  - dir: infra\n    __loadedWorkflow:\n      initCommands: []\n",
+			`turnip.yaml:4:5: unknown field "__loadedWorkflow" in Project`,
+		},
+		{
+			"nested",
+			"version: v1alpha1\nworkflows:\n  tf:\n    terraform:\n      verison: 1.9.0\n",
+			`turnip.yaml:5:7: unknown field "verison" in TerraformAdapter, did you mean "version"?`,
+		},
+		{
+			"type",
+			"version: v1alpha1\nprojects:\n  - dir: infra\n    autoPlan: maybe\n",
+			"turnip.yaml:4: cannot unmarshal !!str `maybe` into bool",
+		},
+		{
+			"syntax",
+			"version: v1alpha1\nprojects:\n\t- dir: infra\n",
+			"turnip.yaml:3: found character that cannot start any token",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Load([]byte(tc.input))
+			var cfgErr *ConfigError
+			if !errors.As(err, &cfgErr) {
+				t.Fatalf("expected a ConfigError, got %v", err)
+			}
+			if err.Error() != tc.expected {
+				t.Errorf("expected error %q, got %q", tc.expected, err.Error())
+			}
+		})
+	}
+}
+
+func TestConfigErrorDetails(t *testing.T) {
+	err := &ConfigError{File: "turnip.yaml", Line: 4, Column: 5, Source: "    autoplan: true", Msg: "unknown field"}
+	expected := "turnip.yaml:4:5: unknown field\n\n   4 |     autoplan: true\n           ^"
+	if err.Details() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, err.Details())
+	}
+}
+
+func TestSchemaIsUpToDate(t *testing.T) {
+	published, err := os.ReadFile("../../schema/turnip.schema.json")
+	if err != nil {
+		t.Fatalf("error reading the published schema: %v", err)
+	}
+	schema, err := Schema()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(schema) != string(published) {
+		t.Error("the published schema is outdated, run make schema")
+	}
+}
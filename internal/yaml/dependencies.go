@@ -0,0 +1,159 @@
+package yaml
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Matches reports whether the dependsOn reference points to the project.
+func (p Project) Matches(ref string) bool {
+	if p.Name != "" && ref == p.Name {
+		return true
+	}
+	dir, workspace, ok := strings.Cut(ref, ":")
+	if path.Clean(dir) != path.Clean(p.Dir) {
+		return false
+	}
+	return !ok || workspace == p.GetWorkspace()
+}
+
+// DisplayName returns the project's name, or its dir and workspace if it doesn't
+// have one.
+func (p Project) DisplayName() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	if ws := p.GetWorkspace(); ws != "" {
+		return p.Dir + ":" + ws
+	}
+	return p.Dir
+}
+
+// DependsOn reports whether p has to wait for dep, either directly or through
+// the dependencies of its dependencies.
+func (c Config) DependsOn(p, dep Project) bool {
+	from, to := c.index(p), c.index(dep)
+	if from < 0 || to < 0 || from == to {
+		return false
+	}
+
+	graph := c.dependencyGraph()
+	visited := make([]bool, len(c.Projects))
+	queue := []int{from}
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		for _, j := range graph[i] {
+			if j == to {
+				return true
+			}
+			if !visited[j] {
+				visited[j] = true
+				queue = append(queue, j)
+			}
+		}
+	}
+	return false
+}
+
+// ValidateDependencies checks that every dependsOn reference points to a
+// project, and that there are no cycles.
+func (c Config) ValidateDependencies() error {
+	return c.validateDependencies(true)
+}
+
+func (c Config) validateDependencies(strict bool) error {
+	if strict {
+		for i, p := range c.Projects {
+			for _, ref := range p.DependsOn {
+				if !c.hasDependency(i, ref) {
+					return fmt.Errorf("project %s: dependency %s not found", p.DisplayName(), ref)
+				}
+			}
+		}
+	}
+
+	graph := c.dependencyGraph()
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make([]int, len(c.Projects))
+	var stack []int
+	var visit func(int) error
+	visit = func(i int) error {
+		state[i] = visiting
+		stack = append(stack, i)
+		for _, j := range graph[i] {
+			switch state[j] {
+			case visiting:
+				return c.cycleError(stack, j)
+			case unvisited:
+				if err := visit(j); err != nil {
+					return err
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[i] = done
+		return nil
+	}
+	for i := range c.Projects {
+		if state[i] == unvisited {
+			if err := visit(i); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// dependencyGraph returns, for every project, the indexes of the projects it
+// directly depends on.
+func (c Config) dependencyGraph() [][]int {
+	graph := make([][]int, len(c.Projects))
+	for i, p := range c.Projects {
+		for _, ref := range p.DependsOn {
+			for j, dep := range c.Projects {
+				if i != j && dep.Matches(ref) {
+					graph[i] = append(graph[i], j)
+				}
+			}
+		}
+	}
+	return graph
+}
+
+func (c Config) hasDependency(i int, ref string) bool {
+	for j, dep := range c.Projects {
+		if i != j && dep.Matches(ref) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c Config) index(p Project) int {
+	for i, q := range c.Projects {
+		if q.Name == p.Name && path.Clean(q.Dir) == path.Clean(p.Dir) && q.GetWorkspace() == p.GetWorkspace() {
+			return i
+		}
+	}
+	return -1
+}
+
+func (c Config) cycleError(stack []int, start int) error {
+	names := make([]string, 0, len(stack)+1)
+	for k := len(stack) - 1; k >= 0; k-- {
+		if stack[k] == start {
+			for _, i := range stack[k:] {
+				names = append(names, c.Projects[i].DisplayName())
+			}
+			break
+		}
+	}
+	names = append(names, c.Projects[start].DisplayName())
+	return fmt.Errorf("dependency cycle: %s", strings.Join(names, " -> "))
+}
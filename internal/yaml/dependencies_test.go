@@ -0,0 +1,76 @@
+package yaml
+
+import "testing"
+
+func TestValidateDependencies(t *testing.T) {
+	workflow := Workflow{Terraform: &TerraformAdapter{}}
+	project := func(name, dir, workspace string, deps ...string) Project {
+		return Project{Name: name, Dir: dir, Workspace: workspace, DependsOn: deps, LoadedWorkflow: workflow}
+	}
+
+	tt := []struct {
+		name     string
+		projects []Project
+		expected string
+	}{
+		{
+			"valid",
+			[]Project{
+				project("network", "infra/network", ""),
+				project("", "infra/cluster", "prod", "network"),
+				project("", "apps", "prod", "infra/cluster:prod", "infra/network"),
+			},
+			"",
+		},
+		{
+			"not found",
+			[]Project{
+				project("", "infra/cluster", "prod", "infra/cluster:dev"),
+			},
+			"project infra/cluster:prod: dependency infra/cluster:dev not found",
+		},
+		{
+			"cycle",
+			[]Project{
+				project("network", "infra/network", "", "apps"),
+				project("cluster", "infra/cluster", "", "network"),
+				project("apps", "apps", "", "cluster"),
+			},
+			"dependency cycle: network -> apps -> cluster -> network",
+		},
+		{
+			"self through dir",
+			[]Project{
+				project("", "infra", "dev"),
+				project("", "infra", "prod", "infra"),
+			},
+			"",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Config{Projects: tc.projects}.ValidateDependencies()
+			switch {
+			case tc.expected == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tc.expected != "" && (err == nil || err.Error() != tc.expected):
+				t.Errorf("expected error %q, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestDependsOn(t *testing.T) {
+	workflow := Workflow{Terraform: &TerraformAdapter{}}
+	network := Project{Name: "network", Dir: "infra/network", LoadedWorkflow: workflow}
+	cluster := Project{Dir: "infra/cluster", DependsOn: []string{"network"}, LoadedWorkflow: workflow}
+	apps := Project{Dir: "apps", DependsOn: []string{"infra/cluster"}, LoadedWorkflow: workflow}
+	cfg := Config{Projects: []Project{network, cluster, apps}}
+
+	if !cfg.DependsOn(apps, network) {
+		t.Error("expected apps to depend on network through cluster")
+	}
+	if cfg.DependsOn(network, apps) {
+		t.Error("expected network not to depend on apps")
+	}
+}
@@ -0,0 +1,83 @@
+package yaml
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// includedConfig is a configuration file included from the root turnip.yaml.
+// It can only declare projects, the workflows are shared from the root.
+type includedConfig struct {
+	Projects  []Project           `yaml:"projects"`
+	Workflows map[string]Workflow `yaml:"workflows"`
+	Include   []string            `yaml:"include"`
+}
+
+// includedConfigV1beta1 is an included file of a v1beta1 configuration.
+type includedConfigV1beta1 struct {
+	Projects  []ProjectV1beta1           `yaml:"projects"`
+	Workflows map[string]WorkflowV1beta1 `yaml:"workflows"`
+	Include   []string                   `yaml:"include"`
+}
+
+// Merge adds the projects of the included file to the configuration. Their
+// dir, and the dependsOn references starting with ./ or ../, are relative to
+// the file's directory. The file has the version of the configuration, and
+// its keys are checked against the policy.
+func (c *Config) Merge(file string, data []byte, policy Policy) error {
+	inc, err := decodeIncluded(file, data, c.Version)
+	if err != nil {
+		return err
+	}
+	if err := policy.CheckKeys(file, inc); err != nil {
+		return err
+	}
+	if len(inc.Workflows) > 0 {
+		return fmt.Errorf("%s: workflows can only be defined in the root turnip.yaml", file)
+	}
+	if len(inc.Include) > 0 {
+		return fmt.Errorf("%s: include can only be set in the root turnip.yaml", file)
+	}
+
+	base := path.Dir(file)
+	for _, p := range inc.Projects {
+		p.Dir = path.Join(base, p.Dir)
+		for i, ref := range p.DependsOn {
+			if strings.HasPrefix(ref, "./") || strings.HasPrefix(ref, "../") {
+				p.DependsOn[i] = path.Join(base, ref)
+			}
+		}
+		w, ok := c.Workflows[p.Workflow]
+		if !ok {
+			return fmt.Errorf("%s: project %s: workflow %s not found", file, p.Dir, p.Workflow)
+		}
+		p.LoadedWorkflow = w
+		c.Projects = append(c.Projects, p)
+	}
+	return nil
+}
+
+func decodeIncluded(file string, data []byte, version string) (includedConfig, error) {
+	if version != V1beta1 {
+		var inc includedConfig
+		err := decodeStrict(file, data, &inc)
+		return inc, err
+	}
+
+	var v includedConfigV1beta1
+	if err := decodeStrict(file, data, &v); err != nil {
+		return includedConfig{}, err
+	}
+	inc := includedConfig{Include: v.Include}
+	for _, p := range v.Projects {
+		inc.Projects = append(inc.Projects, p.Convert())
+	}
+	if len(v.Workflows) > 0 {
+		inc.Workflows = make(map[string]Workflow, len(v.Workflows))
+		for name, w := range v.Workflows {
+			inc.Workflows[name] = w.Convert()
+		}
+	}
+	return inc, nil
+}
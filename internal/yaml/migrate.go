@@ -0,0 +1,180 @@
+package yaml
+
+import (
+	"bytes"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// autoPlotKeys are the v1alpha1 keys replaced by autoPlot, by the adapter
+// that reads them. The rest of the adapters read autoPlan.
+var autoPlotKeys = map[string]string{
+	"pulumi":    "autoPreview",
+	"helmfile":  "autoDiff",
+	"kustomize": "autoDiff",
+}
+
+// Migration rewrites v1alpha1 configuration files as v1beta1, keeping their
+// comments.
+type Migration struct {
+	// Include are the include globs of the root file.
+	Include []string
+	// adapters are the adapters of the root file's workflows, by name.
+	adapters map[string]string
+}
+
+// NewMigration returns the migration of the root configuration file, and the
+// files it includes.
+func NewMigration(root []byte) (*Migration, error) {
+	var cfg struct {
+		Version   string                    `yaml:"version"`
+		Include   []string                  `yaml:"include"`
+		Workflows map[string]map[string]any `yaml:"workflows"`
+	}
+	if err := yaml.Unmarshal(root, &cfg); err != nil {
+		return nil, newConfigError("turnip.yaml", root, err)
+	}
+	if cfg.Version != V1alpha1 {
+		return nil, fmt.Errorf("turnip.yaml: only %s can be migrated, got version %q", V1alpha1, cfg.Version)
+	}
+
+	m := &Migration{Include: cfg.Include, adapters: make(map[string]string)}
+	for name, w := range cfg.Workflows {
+		for key := range w {
+			if _, ok := adapterFields[key]; ok {
+				m.adapters[name] = key
+			}
+		}
+	}
+	return m, nil
+}
+
+// Migrate returns the file as v1beta1. The adapters move under adapter, set
+// by their type, and autoPlot takes the value of the flag the project's
+// adapter read. If the adapter is unknown, e.g. the workflow is the
+// server's, autoPlot is set if any of the flags was.
+func (m *Migration) Migrate(file string, data []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, newConfigError(file, data, err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return data, nil
+	}
+	root := doc.Content[0]
+
+	if v := mappingValue(root, "version"); v != nil {
+		v.Value = V1beta1
+	}
+	if workflows := mappingValue(root, "workflows"); workflows != nil && workflows.Kind == yaml.MappingNode {
+		for i := 1; i < len(workflows.Content); i += 2 {
+			migrateWorkflow(workflows.Content[i])
+		}
+	}
+	if projects := mappingValue(root, "projects"); projects != nil && projects.Kind == yaml.SequenceNode {
+		for _, p := range projects.Content {
+			if err := m.migrateProject(p); err != nil {
+				return nil, &ConfigError{File: file, Line: p.Line, Column: p.Column, Msg: err.Error()}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// migrateWorkflow replaces the adapter's key with adapter, adding its type to
+// its settings.
+func migrateWorkflow(w *yaml.Node) {
+	if w.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(w.Content); i += 2 {
+		key, value := w.Content[i], w.Content[i+1]
+		if _, ok := adapterFields[key.Value]; !ok {
+			continue
+		}
+		typ := []*yaml.Node{
+			{Kind: yaml.ScalarNode, Tag: "!!str", Value: "type"},
+			{Kind: yaml.ScalarNode, Tag: "!!str", Value: key.Value},
+		}
+		if value.Kind == yaml.MappingNode {
+			value.Content = append(typ, value.Content...)
+		} else {
+			// An adapter without settings.
+			w.Content[i+1] = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: typ}
+		}
+		key.Value = "adapter"
+	}
+}
+
+// migrateProject replaces autoPlan, autoPreview and autoDiff with autoPlot,
+// where the first of them was, keeping its comment.
+func (m *Migration) migrateProject(p *yaml.Node) error {
+	if p.Kind != yaml.MappingNode {
+		return nil
+	}
+	adapter, known := "", false
+	if wf := mappingValue(p, "workflow"); wf != nil {
+		adapter, known = m.adapters[wf.Value]
+	}
+	want := "autoPlan"
+	if key, ok := autoPlotKeys[adapter]; ok {
+		want = key
+	}
+
+	autoPlot, at, comment := false, -1, ""
+	content := make([]*yaml.Node, 0, len(p.Content))
+	for i := 0; i+1 < len(p.Content); i += 2 {
+		key, value := p.Content[i], p.Content[i+1]
+		switch key.Value {
+		case "autoPlan", "autoPreview", "autoDiff":
+			var set bool
+			if err := value.Decode(&set); err != nil {
+				return fmt.Errorf("%s: %s", key.Value, err.Error())
+			}
+			if key.Value == want || !known {
+				autoPlot = autoPlot || set
+			}
+			if at < 0 {
+				at, comment = len(content), key.HeadComment
+			}
+		default:
+			content = append(content, key, value)
+		}
+	}
+	if at < 0 {
+		return nil
+	}
+
+	value := "false"
+	if autoPlot {
+		value = "true"
+	}
+	content = append(content[:at], append([]*yaml.Node{
+		{Kind: yaml.ScalarNode, Tag: "!!str", Value: "autoPlot", HeadComment: comment},
+		{Kind: yaml.ScalarNode, Tag: "!!bool", Value: value},
+	}, content[at:]...)...)
+	p.Content = content
+	return nil
+}
+
+// mappingValue returns the value of the key in the mapping, or nil if it's
+// not set.
+func mappingValue(n *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
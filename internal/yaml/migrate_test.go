@@ -0,0 +1,109 @@
+package yaml
+
+import "testing"
+
+const v1alpha1Config = `version: v1alpha1
+# The shared workflows.
+workflows:
+  tf:
+    terraform:
+      version: 1.9.0
+  stacks:
+    pulumi:
+      skipInstall: true
+projects:
+  - dir: infra/network
+    autoPlan: true
+    workflow: tf
+  - dir: infra/app
+    stack: prod
+    # Preview on every change.
+    autoPreview: true
+    autoPlan: false
+    workflow: stacks
+`
+
+func TestMigrate(t *testing.T) {
+	expected := `version: v1beta1
+# The shared workflows.
+workflows:
+  tf:
+    adapter:
+      type: terraform
+      version: 1.9.0
+  stacks:
+    adapter:
+      type: pulumi
+      skipInstall: true
+projects:
+  - dir: infra/network
+    autoPlot: true
+    workflow: tf
+  - dir: infra/app
+    stack: prod
+    # Preview on every change.
+    autoPlot: true
+    workflow: stacks
+`
+	m, err := NewMigration([]byte(v1alpha1Config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := m.Migrate("turnip.yaml", []byte(v1alpha1Config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != expected {
+		t.Fatalf("expected\n%s\ngot\n%s", expected, out)
+	}
+
+	before, err := Load([]byte(v1alpha1Config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := Load(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range after.Projects {
+		b := before.Projects[i]
+		if p.Dir != b.Dir || p.GetAdapterName() != b.GetAdapterName() || p.GetWorkspace() != b.GetWorkspace() {
+			t.Errorf("expected project %s %s %s, got %s %s %s", b.Dir, b.GetAdapterName(), b.GetWorkspace(), p.Dir, p.GetAdapterName(), p.GetWorkspace())
+		}
+	}
+	if !after.Projects[0].AutoPlan || !after.Projects[1].AutoPreview {
+		t.Errorf("expected autoPlot to set the adapter's auto plot flag")
+	}
+}
+
+func TestLoadV1beta1(t *testing.T) {
+	tt := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			"unknown type",
+			"version: v1beta1\nworkflows:\n  tf:\n    adapter:\n      type: terrafrom\n",
+			`turnip.yaml:5:7: unknown adapter type "terrafrom", must be one of custom, helmfile, kustomize, pulumi, terraform, terragrunt, tofu`,
+		},
+		{
+			"adapter field",
+			"version: v1beta1\nworkflows:\n  tf:\n    adapter:\n      type: terraform\n      engine: tofu\n",
+			`turnip.yaml:6:7: unknown field "engine" in TerraformAdapter`,
+		},
+		{
+			"v1alpha1 key",
+			"version: v1beta1\nprojects:\n  - dir: infra\n    autoPlan: true\n",
+			`turnip.yaml:4:5: unknown field "autoPlan" in ProjectV1beta1, did you mean "autoPlot"?`,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Load([]byte(tc.input))
+			if err == nil || err.Error() != tc.expected {
+				t.Errorf("expected error %q, got %v", tc.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package yaml
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Policy restricts what a repository's configuration can set.
+type Policy struct {
+	// Workflows are owned by the server. The projects can use them, but the
+	// configuration can't define workflows with the same names.
+	Workflows map[string]Workflow
+	// AllowedKeys are the keys the configuration can set, as dot separated
+	// paths, e.g. projects.dir. A key allows every key under it, and * matches
+	// any key, e.g. workflows.*.terraform. Every key is allowed if it's empty.
+	//
+	// The keys are checked once the configuration is converted to its loaded
+	// form, so they're the same for every version: a v1beta1 adapter is
+	// checked as workflows.<name>.<type>, and its autoPlot as autoPlan,
+	// autoPreview and autoDiff. The version is always allowed, and the keys
+	// set to their zero value are the same as unset.
+	AllowedKeys []string
+}
+
+// CheckKeys returns an error if the loaded configuration of the file sets a
+// key that's not allowed by the policy.
+func (p Policy) CheckKeys(file string, cfg any) error {
+	if len(p.AllowedKeys) == 0 {
+		return nil
+	}
+
+	allowed := [][]string{{"version"}}
+	for _, k := range p.AllowedKeys {
+		allowed = append(allowed, strings.Split(k, "."))
+	}
+	for _, key := range keyPaths(reflect.ValueOf(cfg), nil) {
+		if !slices.ContainsFunc(allowed, func(a []string) bool { return keyHasPrefix(key, a) }) {
+			return fmt.Errorf("%s: key %s is not allowed by the server", file, strings.Join(key, "."))
+		}
+	}
+	return nil
+}
+
+// keyPaths returns the paths of the keys set in the value, down to the
+// scalar values, named after their yaml tags. The items of a slice share the
+// path of the slice.
+func keyPaths(v reflect.Value, prefix []string) [][]string {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return keyPaths(v.Elem(), prefix)
+	case reflect.Struct:
+		var out [][]string
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+			if !f.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(f.Name)
+			}
+			out = append(out, keyPaths(v.Field(i), append(slices.Clip(prefix), name))...)
+		}
+		return out
+	case reflect.Map:
+		var out [][]string
+		iter := v.MapRange()
+		for iter.Next() {
+			out = append(out, keyPaths(iter.Value(), append(slices.Clip(prefix), fmt.Sprint(iter.Key())))...)
+		}
+		if v.Len() == 0 && !v.IsNil() {
+			out = append(out, prefix)
+		}
+		slices.SortFunc(out, func(a, b []string) int { return slices.Compare(a, b) })
+		return out
+	case reflect.Slice, reflect.Array:
+		var out [][]string
+		for i := 0; i < v.Len(); i++ {
+			out = append(out, keyPaths(v.Index(i), prefix)...)
+		}
+		if v.Len() == 0 && v.Kind() == reflect.Slice && !v.IsNil() {
+			out = append(out, prefix)
+		}
+		return out
+	default:
+		if v.IsZero() {
+			return nil
+		}
+		return [][]string{prefix}
+	}
+}
+
+func keyHasPrefix(key, prefix []string) bool {
+	if len(prefix) > len(key) {
+		return false
+	}
+	for i, p := range prefix {
+		if p != "*" && p != key[i] {
+			return false
+		}
+	}
+	return true
+}
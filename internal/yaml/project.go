@@ -2,12 +2,19 @@ package yaml
 
 import (
 	"fmt"
+	"regexp"
 
 	"gopkg.in/yaml.v3"
 )
 
+var nameRegexp = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
 type Project struct {
-	Dir string `yaml:"dir"`
+	// Name optionally identifies the project in its status context, the
+	// dependsOn of other projects, comments and API requests. Unlike its dir,
+	// it's kept when the project moves.
+	Name string `yaml:"name"`
+	Dir  string `yaml:"dir"`
 
 	Stack       string `yaml:"stack"`
 	Workspace   string `yaml:"workspace"`
@@ -24,6 +31,10 @@ type Project struct {
 
 	WhenModified []string `yaml:"whenModified"`
 
+	// DependsOn are the projects that must succeed before this one runs. They
+	// are referenced by name, dir, or dir and workspace as dir:workspace.
+	DependsOn []string `yaml:"dependsOn"`
+
 	Workflow       string   `yaml:"workflow"`
 	LoadedWorkflow Workflow `yaml:"__loadedWorkflow"`
 }
@@ -38,52 +49,27 @@ func (p Project) ToYAML() ([]byte, error) {
 	return yaml.Marshal(p)
 }
 
-func (p Project) GetAutoPlot() bool {
-	a, err := p.LoadedWorkflow.GetAdapter()
-	if err != nil {
-		return false
-	}
-	switch a.GetName() {
-	case "helmfile":
-		return p.AutoDiff
-	case "pulumi":
-		return p.AutoPreview
-	case "terraform":
-		return p.AutoPlan
-	}
-	return false
-}
-
 func (p Project) Validate() error {
 	if p.Workflow == "" {
 		return fmt.Errorf("project %s: workflow not set", p.Dir)
 	}
-
-	return nil
-}
-
-func (p Project) GetWorkspace() string {
-	a, err := p.LoadedWorkflow.GetAdapter()
-	if err != nil {
-		return ""
+	if p.Name != "" && !nameRegexp.MatchString(p.Name) {
+		return fmt.Errorf("invalid name %q, it must start with a letter or number, followed by letters, numbers, '.', '_' or '-'", p.Name)
 	}
-	return a.GetWorkspace(p)
+
+	return validateEnv(p.Env)
 }
 
-func (p Project) GetPlotName() string {
-	a, err := p.LoadedWorkflow.GetAdapter()
-	if err != nil {
-		return ""
-	}
-	return a.GetPlotName()
+func (p Project) GetEnv() []string {
+	return envToSlice(p.Env)
 }
 
-func (p Project) GetLiftName() string {
+func (p Project) GetWorkspace() string {
 	a, err := p.LoadedWorkflow.GetAdapter()
 	if err != nil {
 		return ""
 	}
-	return a.GetLiftName()
+	return a.GetWorkspace(p)
 }
 
 func (p Project) GetAdapterName() string {
@@ -0,0 +1,106 @@
+package yaml
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// SchemaID is the URL the JSON Schema is published at.
+const SchemaID = "https://raw.githubusercontent.com/ivanvc/turnip/main/schema/turnip.schema.json"
+
+// schemaEnums are the allowed values of the fields, by type and field name.
+var schemaEnums = map[string][]string{
+	"Config.Version":        {V1alpha1},
+	"ConfigV1beta1.Version": {V1beta1},
+	"AutodiscoverRule.Type": AutodiscoverTypes,
+}
+
+// schemaProvider is implemented by the types that decode their node
+// themselves, to describe it.
+type schemaProvider interface {
+	jsonSchema(defs map[string]any) map[string]any
+}
+
+// Schema returns the JSON Schema of turnip.yaml, generated from the types of
+// every version. The internal keys are left out, and unknown keys are not
+// allowed.
+func Schema() ([]byte, error) {
+	defs := make(map[string]any)
+	schema := map[string]any{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"$id":     SchemaID,
+		"title":   "turnip.yaml",
+		// Any rather than one of, as the included files match both.
+		"anyOf": []any{
+			typeSchema(reflect.TypeOf(Config{}), defs),
+			typeSchema(reflect.TypeOf(ConfigV1beta1{}), defs),
+		},
+		"$defs": defs,
+	}
+	out, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// typeSchema returns the schema of the type, adding the structs to defs and
+// referencing them.
+func typeSchema(t reflect.Type, defs map[string]any) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(time.Duration(0)) {
+		return map[string]any{"type": []string{"string", "integer"}, "description": "A duration, e.g. 5m or 1h30m."}
+	}
+
+	if s, ok := reflect.Zero(t).Interface().(schemaProvider); ok {
+		return s.jsonSchema(defs)
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		ref := map[string]any{"$ref": "#/$defs/" + t.Name()}
+		if _, ok := defs[t.Name()]; ok {
+			return ref
+		}
+		props := make(map[string]any)
+		def := map[string]any{
+			"type":                 "object",
+			"additionalProperties": false,
+			"properties":           props,
+		}
+		// Set before walking the fields, in case the type is recursive.
+		defs[t.Name()] = def
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, ok := yamlKey(f)
+			if !ok || strings.HasPrefix(name, internalKeyPrefix) {
+				continue
+			}
+			prop := typeSchema(f.Type, defs)
+			if enum, ok := schemaEnums[t.Name()+"."+f.Name]; ok {
+				prop["enum"] = enum
+			}
+			props[name] = prop
+		}
+		return ref
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem(), defs)}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem(), defs)}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	default:
+		return map[string]any{}
+	}
+}
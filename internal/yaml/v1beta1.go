@@ -0,0 +1,204 @@
+package yaml
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	V1alpha1 = "v1alpha1"
+	V1beta1  = "v1beta1"
+)
+
+// Versions are the supported versions of turnip.yaml.
+var Versions = []string{V1alpha1, V1beta1}
+
+// ConfigV1beta1 is the v1beta1 turnip.yaml. It's converted to Config once
+// loaded.
+type ConfigV1beta1 struct {
+	Version      string                     `yaml:"version"`
+	Projects     []ProjectV1beta1           `yaml:"projects"`
+	Workflows    map[string]WorkflowV1beta1 `yaml:"workflows"`
+	Include      []string                   `yaml:"include"`
+	Autodiscover *Autodiscover              `yaml:"autodiscover"`
+}
+
+// ProjectV1beta1 is a v1beta1 project. AutoPlot replaces the adapter specific
+// autoPlan, autoPreview and autoDiff.
+type ProjectV1beta1 struct {
+	Name string `yaml:"name"`
+	Dir  string `yaml:"dir"`
+
+	Stack       string `yaml:"stack"`
+	Workspace   string `yaml:"workspace"`
+	Environment string `yaml:"environment"`
+
+	AutoPlot bool  `yaml:"autoPlot"`
+	AutoLock *bool `yaml:"autoLock"`
+
+	PodAnnotations map[string]string `yaml:"podAnnotations"`
+	Env            map[string]string `yaml:"env"`
+
+	WhenModified []string `yaml:"whenModified"`
+	DependsOn    []string `yaml:"dependsOn"`
+
+	Workflow string `yaml:"workflow"`
+}
+
+// WorkflowV1beta1 is a v1beta1 workflow, with its adapter tagged by type.
+type WorkflowV1beta1 struct {
+	Adapter AdapterV1beta1 `yaml:"adapter"`
+
+	PodAnnotations map[string]string `yaml:"podAnnotations"`
+	Env            map[string]string `yaml:"env"`
+	InitCommands   []Command         `yaml:"initCommands"`
+	RedactPatterns []string          `yaml:"redactPatterns"`
+
+	PrePlot  []Command `yaml:"prePlot"`
+	PostPlot []Command `yaml:"postPlot"`
+	PreLift  []Command `yaml:"preLift"`
+	PostLift []Command `yaml:"postLift"`
+}
+
+// AdapterV1beta1 is an adapter set by its type, next to its settings, e.g.
+//
+//	adapter:
+//	  type: terraform
+//	  version: 1.9.0
+type AdapterV1beta1 struct {
+	Adapter
+}
+
+func (a *AdapterV1beta1) UnmarshalYAML(n *yaml.Node) error {
+	typ, settings, err := splitAdapterNode(n)
+	if err != nil {
+		return err
+	}
+	adapter, _ := newAdapter(typ)
+	if err := settings.Decode(adapter); err != nil {
+		return err
+	}
+	a.Adapter = adapter
+	return nil
+}
+
+// checkYAMLFields checks the adapter's settings against the fields of its
+// type.
+func (AdapterV1beta1) checkYAMLFields(n *yaml.Node) *ConfigError {
+	typ, settings, err := splitAdapterNode(n)
+	if err != nil {
+		return &ConfigError{Line: n.Line, Column: n.Column, Msg: err.Error()}
+	}
+	adapter, _ := newAdapter(typ)
+	return checkFields(settings, reflect.TypeOf(adapter))
+}
+
+// jsonSchema returns one schema per adapter type, with its settings.
+func (AdapterV1beta1) jsonSchema(defs map[string]any) map[string]any {
+	variants := make([]any, 0, len(adapterFields))
+	for _, typ := range adapterNames() {
+		adapter, _ := newAdapter(typ)
+		t := reflect.TypeOf(adapter).Elem()
+		typeSchema(t, defs)
+		def := defs[t.Name()].(map[string]any)
+		props := maps.Clone(def["properties"].(map[string]any))
+		props["type"] = map[string]any{"const": typ}
+		variants = append(variants, map[string]any{
+			"type":                 "object",
+			"additionalProperties": false,
+			"required":             []string{"type"},
+			"properties":           props,
+		})
+	}
+	return map[string]any{"oneOf": variants}
+}
+
+// splitAdapterNode returns the adapter's type, and a node with the rest of its
+// settings.
+func splitAdapterNode(n *yaml.Node) (string, *yaml.Node, error) {
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	if n.Kind != yaml.MappingNode {
+		return "", nil, fmt.Errorf("adapter must be a mapping with its type")
+	}
+	settings := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: n.Line, Column: n.Column}
+	typ := ""
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == "type" {
+			typ = n.Content[i+1].Value
+			continue
+		}
+		settings.Content = append(settings.Content, n.Content[i], n.Content[i+1])
+	}
+	if _, ok := adapterFields[typ]; !ok {
+		names := adapterNames()
+		if typ == "" {
+			return "", nil, fmt.Errorf("adapter type not set, must be one of %s", strings.Join(names, ", "))
+		}
+		return "", nil, fmt.Errorf("unknown adapter type %q, must be one of %s", typ, strings.Join(names, ", "))
+	}
+	return typ, settings, nil
+}
+
+// Convert returns the configuration in its loaded form.
+func (c ConfigV1beta1) Convert() Config {
+	cfg := Config{
+		Version:      c.Version,
+		Include:      c.Include,
+		Autodiscover: c.Autodiscover,
+	}
+	for _, p := range c.Projects {
+		cfg.Projects = append(cfg.Projects, p.Convert())
+	}
+	if c.Workflows != nil {
+		cfg.Workflows = make(map[string]Workflow, len(c.Workflows))
+		for name, w := range c.Workflows {
+			cfg.Workflows[name] = w.Convert()
+		}
+	}
+	return cfg
+}
+
+// Convert returns the project in its loaded form, with AutoPlot setting the
+// auto plot flags of every adapter.
+func (p ProjectV1beta1) Convert() Project {
+	return Project{
+		Name:           p.Name,
+		Dir:            p.Dir,
+		Stack:          p.Stack,
+		Workspace:      p.Workspace,
+		Environment:    p.Environment,
+		AutoPlan:       p.AutoPlot,
+		AutoPreview:    p.AutoPlot,
+		AutoDiff:       p.AutoPlot,
+		AutoLock:       p.AutoLock,
+		PodAnnotations: p.PodAnnotations,
+		Env:            p.Env,
+		WhenModified:   p.WhenModified,
+		DependsOn:      p.DependsOn,
+		Workflow:       p.Workflow,
+	}
+}
+
+// Convert returns the workflow in its loaded form.
+func (w WorkflowV1beta1) Convert() Workflow {
+	wf := Workflow{
+		PodAnnotations: w.PodAnnotations,
+		Env:            w.Env,
+		InitCommands:   w.InitCommands,
+		RedactPatterns: w.RedactPatterns,
+		PrePlot:        w.PrePlot,
+		PostPlot:       w.PostPlot,
+		PreLift:        w.PreLift,
+		PostLift:       w.PostLift,
+	}
+	if w.Adapter.Adapter != nil {
+		wf.SetAdapter(w.Adapter.Adapter)
+	}
+	return wf
+}
@@ -3,33 +3,97 @@ package yaml
 import (
 	"fmt"
 	"reflect"
-	"slices"
+	"sort"
+	"strings"
 )
 
 type Workflow struct {
-	Terraform *TerraformAdapter `yaml:"terraform"`
-	Helmfile  *HelmfileAdapter  `yaml:"helmfile"`
-	Pulumi    *PulumiAdapter    `yaml:"pulumi"`
+	Terraform  *TerraformAdapter  `yaml:"terraform"`
+	Tofu       *TofuAdapter       `yaml:"tofu"`
+	Terragrunt *TerragruntAdapter `yaml:"terragrunt"`
+	Helmfile   *HelmfileAdapter   `yaml:"helmfile"`
+	Kustomize  *KustomizeAdapter  `yaml:"kustomize"`
+	Custom     *CustomAdapter     `yaml:"custom"`
+	Pulumi     *PulumiAdapter     `yaml:"pulumi"`
 
 	PodAnnotations map[string]string `yaml:"podAnnotations"`
 	Env            map[string]string `yaml:"env"`
 	InitCommands   []Command         `yaml:"initCommands"`
+	RedactPatterns []string          `yaml:"redactPatterns"`
+
+	// The commands run before and after plot and lift. A failing command
+	// fails the job.
+	PrePlot  []Command `yaml:"prePlot"`
+	PostPlot []Command `yaml:"postPlot"`
+	PreLift  []Command `yaml:"preLift"`
+	PostLift []Command `yaml:"postLift"`
+}
+
+// adapterFields holds the index of the Workflow field of every adapter, by
+// the adapter's name. Adding an adapter only takes adding its field.
+var adapterFields = func() map[string]int {
+	adapterType := reflect.TypeOf((*Adapter)(nil)).Elem()
+	fields := make(map[string]int)
+	t := reflect.TypeOf(Workflow{})
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Type.Kind() == reflect.Pointer && f.Type.Implements(adapterType) {
+			fields[reflect.New(f.Type.Elem()).Interface().(Adapter).GetName()] = i
+		}
+	}
+	return fields
+}()
+
+// adapterNames returns the sorted names of the adapters.
+func adapterNames() []string {
+	names := make([]string, 0, len(adapterFields))
+	for name := range adapterFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newAdapter returns an empty adapter by its name.
+func newAdapter(name string) (Adapter, bool) {
+	i, ok := adapterFields[name]
+	if !ok {
+		return nil, false
+	}
+	return reflect.New(reflect.TypeOf(Workflow{}).Field(i).Type.Elem()).Interface().(Adapter), true
 }
 
 func (w Workflow) GetAdapter() (Adapter, error) {
-	adapters := []Adapter{w.Pulumi, w.Terraform, w.Helmfile}
-	adapters = slices.DeleteFunc(adapters, func(a Adapter) bool {
-		return reflect.ValueOf(a).IsNil()
-	})
+	v := reflect.ValueOf(w)
+	adapters := make([]Adapter, 0, 1)
+	for _, name := range adapterNames() {
+		if f := v.Field(adapterFields[name]); !f.IsNil() {
+			adapters = append(adapters, f.Interface().(Adapter))
+		}
+	}
 	if len(adapters) == 0 {
-		return nil, fmt.Errorf("no adapters set must be one of Pulumi, Terraform, or Helmfile")
+		return nil, fmt.Errorf("no adapters set must be one of %s", strings.Join(adapterNames(), ", "))
 	}
 	if len(adapters) > 1 {
-		return nil, fmt.Errorf("multiple adapters set must be only one of Pulumi, Terraform, or Helmfile")
+		return nil, fmt.Errorf("multiple adapters set must be only one of %s", strings.Join(adapterNames(), ", "))
 	}
 	return adapters[0], nil
 }
 
+// SetAdapter sets the workflow's adapter, unsetting any other. The adapter
+// is a pointer, as returned by GetAdapter.
+func (w *Workflow) SetAdapter(a Adapter) {
+	v := reflect.ValueOf(w).Elem()
+	for _, i := range adapterFields {
+		f := v.Field(i)
+		if f.Type() == reflect.TypeOf(a) {
+			f.Set(reflect.ValueOf(a))
+		} else {
+			f.Set(reflect.Zero(f.Type()))
+		}
+	}
+}
+
 func (w Workflow) Validate() error {
 	adapter, err := w.GetAdapter()
 	if err != nil {
@@ -39,7 +103,7 @@ func (w Workflow) Validate() error {
 		return fmt.Errorf("adapter %s: %s", adapter.GetName(), err.Error())
 	}
 
-	return nil
+	return validateEnv(w.Env)
 }
 
 func (w Workflow) GetEnv() []string {
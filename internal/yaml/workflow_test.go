@@ -0,0 +1,62 @@
+package yaml
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSetAdapter(t *testing.T) {
+	w := Workflow{Terraform: &TerraformAdapter{Version: "1.9.0"}}
+	a, err := w.GetAdapter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w.SetAdapter(a.WithoutInstall())
+	expected := &TerraformAdapter{SkipInstall: true}
+	if !reflect.DeepEqual(w.Terraform, expected) {
+		t.Errorf("expected %+v, got %+v", expected, w.Terraform)
+	}
+	if a.(*TerraformAdapter).Version != "1.9.0" {
+		t.Error("expected the original adapter to be unchanged")
+	}
+
+	w.SetAdapter(&TofuAdapter{})
+	if w.Terraform != nil || w.Tofu == nil {
+		t.Errorf("expected only the tofu adapter to be set, got %+v", w)
+	}
+}
+
+func TestAdapterNames(t *testing.T) {
+	expected := []string{"custom", "helmfile", "kustomize", "pulumi", "terraform", "terragrunt", "tofu"}
+	if names := adapterNames(); !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+	for _, name := range expected {
+		if a, ok := newAdapter(name); !ok || a.GetName() != name {
+			t.Errorf("expected an empty %s adapter, got %v", name, a)
+		}
+	}
+}
+
+func TestTerragruntAdapterValidate(t *testing.T) {
+	tt := []struct {
+		name      string
+		adapter   TerragruntAdapter
+		expectErr bool
+	}{
+		{"versions", TerragruntAdapter{Version: "0.67.0", EngineVersion: "1.9.0"}, false},
+		{"tofu engine", TerragruntAdapter{Version: "0.67.0", Engine: "tofu", EngineVersion: "1.8.0"}, false},
+		{"skip install", TerragruntAdapter{SkipInstall: true}, false},
+		{"missing engine version", TerragruntAdapter{Version: "0.67.0"}, true},
+		{"missing version", TerragruntAdapter{EngineVersion: "1.9.0"}, true},
+		{"unknown engine", TerragruntAdapter{Version: "0.67.0", Engine: "pulumi", EngineVersion: "1.9.0"}, true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.adapter.Validate(); tc.expectErr != (err != nil) {
+				t.Errorf("expected error %v, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,6 @@
+package turnip
+
+// MaxMessageSize is the size limit of the messages exchanged between the jobs
+// and the server. Both ends set it explicitly, instead of relying on gRPC's
+// default.
+const MaxMessageSize = 16 << 20